@@ -1,11 +1,12 @@
 package main
 
 import (
-	"cellMachine/pkg/gui"
+	"cellMachine/pkg/metrics"
+	"cellMachine/pkg/render"
 	"cellMachine/pkg/sim"
 	"cellMachine/pkg/utils"
+	"flag"
 	"fmt"
-	"github.com/andlabs/ui"
 	"log"
 	"os"
 )
@@ -36,34 +37,163 @@ func showInfo() {
 	fmt.Println()
 }
 
+// command line options
+type options struct {
+	configPath string
+	headless   bool
+	turns      uint64
+	loadPath   string
+	savePath   string
+	checkpoint uint64
+	metrics    string
+	metricsFmt string
+	lineage    string
+	frames     string
+	frameEvery uint64
+	cellSize   int
+	gif        string
+	gifEvery   uint64
+	strict     bool
+	validate   bool
+}
+
+func parseOptions() options {
+	var opts options
+	flag.BoolVar(&opts.headless, "headless", false, "run the simulation without a window")
+	flag.Uint64Var(&opts.turns, "turns", 0, "number of turns for a headless run (0 - until all entities are dead)")
+	flag.StringVar(&opts.loadPath, "load", "", "resume the simulation from a snapshot file")
+	flag.StringVar(&opts.savePath, "save", "", "save a snapshot to the file on exit")
+	flag.Uint64Var(&opts.checkpoint, "checkpoint", 0, "also save a snapshot every N turns (requires -save)")
+	flag.StringVar(&opts.metrics, "metrics", "", "write per-turn statistics to the file")
+	flag.StringVar(&opts.metricsFmt, "metrics-format", "", "format of statistics: csv or json (detected by file extension by default)")
+	flag.StringVar(&opts.lineage, "lineage", "", "track lineage of entities and write it on exit (.nwk for Newick, JSON otherwise)")
+	flag.StringVar(&opts.frames, "frames", "", "write PNG frames of the field to the directory")
+	flag.Uint64Var(&opts.frameEvery, "frame-every", 10, "write a frame every N turns")
+	flag.IntVar(&opts.cellSize, "cell-size", render.DefaultCellSize, "size of a cell in pixels for frames")
+	flag.StringVar(&opts.gif, "gif", "", "record an animated GIF of the simulation to the file")
+	flag.Uint64Var(&opts.gifEvery, "gif-every", 10, "capture a GIF frame every N turns")
+	flag.BoolVar(&opts.strict, "strict", false, "refuse to start if config has any problem")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [validate] [config.json]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "validate" {
+		opts.validate = true
+		args = args[1:]
+	}
+	opts.configPath = "config.json"
+	if len(args) > 0 {
+		opts.configPath = args[0]
+	}
+	return opts
+}
+
 func main() {
+	opts := parseOptions()
+
 	showInfo()
 	initLog()
 	Log.Println("Application initialization...")
 
-	configPath := "config.json"
-	args := os.Args[1:]
-	if len(args) > 0 {
-		configPath = args[0]
+	if opts.validate {
+		runValidation(opts)
+	} else if opts.headless {
+		runHeadless(opts)
+	} else {
+		runWindow(opts)
+	}
+	Log.Println("Closing application...")
+}
+
+func runValidation(opts options) {
+	problems, err := sim.ValidateConfig(opts.configPath)
+	if err != nil {
+		Error.Printf("Cannot read config %s: %s", opts.configPath, err.Error())
+		os.Exit(1)
+	}
+	for _, problem := range problems {
+		fmt.Println(problem.Error())
 	}
+	if len(problems) > 0 {
+		fmt.Printf("Config %s has %d problems\n", opts.configPath, len(problems))
+		os.Exit(1)
+	}
+	fmt.Printf("Config %s is valid\n", opts.configPath)
+}
 
-	closeApp := make(chan bool)
-	composerChan := make(chan utils.FieldComposer)
-	readyChan := make(chan utils.Ready)
+func initSimulator(simulator *sim.Simulator, opts options, composerChan chan utils.FieldComposer) {
+	simulator.Strict = opts.strict
+	err := simulator.Init(opts.configPath, composerChan)
+	if err != nil {
+		Error.Printf("Cannot initialize simulation: %s", err.Error())
+		os.Exit(1)
+	}
+	if opts.loadPath != "" {
+		err := simulator.LoadSnapshot(opts.loadPath)
+		if err != nil {
+			Error.Printf("Cannot load snapshot %s: %s", opts.loadPath, err.Error())
+			os.Exit(1)
+		}
+	}
+	if opts.savePath != "" && opts.checkpoint > 0 {
+		simulator.SetCheckpoint(opts.savePath, opts.checkpoint)
+	}
+	if opts.metrics != "" {
+		writer, err := metrics.NewWriter(opts.metrics, opts.metricsFmt)
+		if err != nil {
+			Error.Printf("Cannot create metrics file %s: %s", opts.metrics, err.Error())
+			os.Exit(1)
+		}
+		simulator.SetMetrics(writer)
+	}
+	if opts.lineage != "" {
+		simulator.EnableLineage()
+	}
+	if opts.frames != "" {
+		writer, err := render.NewFrameWriter(opts.frames, opts.cellSize)
+		if err != nil {
+			Error.Printf("Cannot create frames directory %s: %s", opts.frames, err.Error())
+			os.Exit(1)
+		}
+		simulator.AddRecorder(writer, opts.frameEvery)
+	}
+	if opts.gif != "" {
+		err := simulator.StartRecording(opts.gif, opts.gifEvery)
+		if err != nil {
+			Error.Printf("Cannot start recording: %s", err.Error())
+		}
+	}
+}
 
-	core := gui.Uicore{CloseApp: closeApp, ComposerChan: composerChan, ReadyChan: readyChan}
-	go ui.Main(core.Init)
+func finishSimulator(simulator *sim.Simulator, opts options) {
+	simulator.Close()
+	if opts.lineage != "" {
+		err := simulator.SaveLineage(opts.lineage)
+		if err != nil {
+			Error.Printf("Cannot save lineage %s: %s", opts.lineage, err.Error())
+		}
+	}
+	if opts.savePath != "" {
+		err := simulator.SaveSnapshot(opts.savePath)
+		if err != nil {
+			Error.Printf("Cannot save snapshot %s: %s", opts.savePath, err.Error())
+		}
+	}
+}
 
+func runHeadless(opts options) {
 	var simulator sim.Simulator
-	simulator.Init(configPath, composerChan)
-
-	// waiting for UI initialisation
-	<-readyChan
-	go simulator.Start()
+	initSimulator(&simulator, opts, nil)
 
-	<-closeApp
-	simulator.Stop()
-	close(closeApp)
-	close(composerChan)
-	Log.Println("Closing application...")
+	info := simulator.Run(opts.turns)
+	if info.Entities() == 0 {
+		fmt.Println("Simulation finished: all entities are dead")
+	} else {
+		fmt.Println("Simulation finished: turn limit is reached")
+	}
+	fmt.Println(info.String())
+	finishSimulator(&simulator, opts)
 }
@@ -3,8 +3,10 @@ package Cell
 import (
 	"cellMachine/pkg/utils"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
+	"time"
 )
 
 const (
@@ -18,11 +20,6 @@ const (
 	foodDropMaxR   = 12
 )
 
-var (
-	MaxAntibiotic float64
-	MinAntibiotic float64
-)
-
 // CellField
 
 type CellField struct {
@@ -31,43 +28,86 @@ type CellField struct {
 	W, H          int
 	entityCount   uint64
 	foodDropCount uint32
-	dropFood      bool
+	foodDrop      *FoodDrop
+	resources     Resources
+	diffusion     Diffusion
+	topology      Topology
+	offsets       neighbourOffsets
+	// old levels of a substance during diffusion
+	diffusionBuffer [][]float64
+	// zero layer is the default coloring, next ones are nutrients and stressors
+	layer int
+	// counters of the current turn
+	stats FieldStats
+	// mutations of every trait since the start, indexed as traits
+	mutations []uint64
+	// entities killed and put between turns
+	wiped   uint64
+	dropped uint64
+	turn    uint64
+	nextID  uint64
+	lineage *LineageLog
+	seed    int64
+	// every random decision of the simulation is made by this source
+	source *randomSource
+	rng    *rand.Rand
 }
 
+// SetSeed reseeds the random source of the field, so the simulation can be replayed
+func (field *CellField) SetSeed(seed int64) {
+	field.seed = seed
+	field.rng.Seed(seed)
+}
+
+func (field *CellField) Seed() int64 {
+	return field.seed
+}
+
+// DropFood enables the default random food drops
 func (field *CellField) DropFood(enable bool) {
-	field.dropFood = enable
+	if enable {
+		drop := DefaultFoodDrop()
+		field.foodDrop = &drop
+	} else {
+		field.foodDrop = nil
+	}
 }
 
 func (field *CellField) EntityCount() uint64 {
 	return field.entityCount
 }
 
-func (field *CellField) Divide(e Entity, x, y int) {
-	// make an array with free cells and iterate through them
-	emptyCells := make([]utils.Position, 0)
-	for i := x - 1; i <= x+1; i++ {
-		for j := y - 1; j <= y+1; j++ {
-			if i != x || j != y {
-				posX := (i + field.W) % field.W
-				posY := (j + field.H) % field.H
-				if field.newCells[posX][posY].entity == nil {
-					emptyCells = append(emptyCells, utils.Position{posX, posY})
-				}
-			}
-		}
+func (field *CellField) Resources() Resources {
+	return field.resources
+}
+
+// Layers returns names of field coloring modes
+func (field *CellField) Layers() []string {
+	layers := []string{"default"}
+	for _, name := range field.resources.Nutrients {
+		layers = append(layers, "nutrient: "+name)
+	}
+	for _, name := range field.resources.Stressors {
+		layers = append(layers, "stressor: "+name)
 	}
-	emptyCount := len(emptyCells)
-	if emptyCount > 3 {
-		pos := rand.Intn(emptyCount)
-		field.putEntityToNew(e, emptyCells[pos].X, emptyCells[pos].Y)
-		if emptyCount > 4 {
-			field.putEntityToNew(e, x, y)
+	return layers
+}
+
+func (field *CellField) SetLayer(layer int) {
+	if layer < 0 || layer >= len(field.Layers()) {
+		layer = 0
+	}
+	field.layer = layer
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			field.cells[i][j].updateColor()
 		}
 	}
 }
 
 func (field *CellField) MakeComposer() utils.FieldComposer {
 	composer := utils.MakeFieldComposer(field.W, field.H)
+	composer.Hex = field.topology.Neighbourhood == NeighbourhoodHex
 
 	for i := 0; i < field.W; i++ {
 		for j := 0; j < field.H; j++ {
@@ -87,20 +127,90 @@ func (field *CellField) MakeComposer() utils.FieldComposer {
 	return composer
 }
 
-func (field *CellField) putEntityToNew(e Entity, x, y int) {
+func (field *CellField) CellInfo(x, y int) (utils.CellInfo, error) {
+	if x >= field.W || x < 0 || y >= field.H || y < 0 {
+		return utils.CellInfo{}, errors.New("invalid index")
+	}
+	c := &field.cells[x][y]
+	info := utils.CellInfo{
+		X:             x,
+		Y:             y,
+		FoodStorage:   c.nutrients[0],
+		MaxFood:       c.maxNutrients[0],
+		BadConditions: c.stressors[0],
+	}
+	for i, name := range field.resources.Nutrients {
+		info.Nutrients = append(info.Nutrients, utils.Level{Name: name, Value: c.nutrients[i], Max: c.maxNutrients[i]})
+	}
+	for i, name := range field.resources.Stressors {
+		info.Stressors = append(info.Stressors, utils.Level{Name: name, Value: c.stressors[i]})
+	}
+	if c.entity != nil {
+		info.Entity = &utils.EntityInfo{
+			Resistance:      c.entity.resistances[0],
+			GrownRateBase:   c.entity.grownRateBase,
+			ConsumptionBase: c.entity.needs[0],
+			MutationChance:  c.entity.mutator.mutationChance,
+			Size:            c.entity.size,
+			ID:              c.entity.lineage.ID,
+			ParentID:        c.entity.lineage.ParentID,
+			TypeName:        c.entity.lineage.TypeName,
+			Generation:      c.entity.lineage.Generation,
+			BirthTurn:       c.entity.lineage.BirthTurn,
+			Age:             c.entity.age,
+			MaxAge:          c.entity.maxAge,
+		}
+		if m := c.entity.motility; m != nil {
+			info.Entity.Motility = fmt.Sprintf("%s, chance %g, cost %g", m.Rule, m.Probability, m.Cost)
+			if m.Rule == MoveChemotaxis && m.nutrient >= 0 {
+				info.Entity.Motility += ", to " + field.resources.Nutrients[m.nutrient]
+			} else if m.Rule == MoveChemotaxis && m.stressor >= 0 {
+				info.Entity.Motility += ", from " + field.resources.Stressors[m.stressor]
+			}
+		}
+		for i, name := range field.resources.Nutrients {
+			info.Entity.Needs = append(info.Entity.Needs, utils.Level{Name: name, Value: c.entity.need(i)})
+		}
+		for i, name := range field.resources.Stressors {
+			info.Entity.Resistances = append(info.Entity.Resistances, utils.Level{Name: name, Value: c.entity.resistance(i)})
+		}
+		offset := len(c.entity.traits) - len(field.resources.Genes)
+		for i, name := range field.resources.Genes {
+			info.Entity.Genes = append(info.Entity.Genes, utils.Level{Name: name, Value: c.entity.traits[offset+i]})
+		}
+	}
+	return info, nil
+}
+
+func (field *CellField) putEntityToNew(e Entity, size utils.Size, x, y int) {
 	if field.newCells[x][y].entity == nil {
 		field.entityCount++
 	}
+	field.stats.Births++
 	field.newCells[x][y].entity = NewEntityFromEntity(e)
+	field.newCells[x][y].entity.size = size
 	field.newCells[x][y].entity.SetParent(&field.newCells[x][y])
+	field.registerEntity(field.newCells[x][y].entity, e.lineage.ID, e.lineage.Generation+1, field.turn, x, y)
 }
 
 func (field *CellField) putEntity(e Entity, x, y int) {
 	if field.cells[x][y].entity == nil {
 		field.entityCount++
+	} else {
+		field.unregisterEntity(field.cells[x][y].entity, field.turn+1)
+		field.wiped++
 	}
+	field.dropped++
 	field.cells[x][y].entity = NewEntityFromEntity(e)
 	field.cells[x][y].entity.SetParent(&field.newCells[x][y])
+	field.registerEntity(field.cells[x][y].entity, 0, 0, field.turn+1, x, y)
+}
+
+// FinishSeeding makes entities put so far the initial population, so they are not counted
+// as births (and replaced ones as wiped) in stats of the first turn
+func (field *CellField) FinishSeeding() {
+	field.dropped = 0
+	field.wiped = 0
 }
 
 func (field *CellField) copyCellsToNew() {
@@ -127,30 +237,27 @@ func (field *CellField) copyCellsFromNew() {
 }
 
 func (field *CellField) Update() {
+	field.turn++
+	field.stats = FieldStats{}
+	field.stats.Deaths[DeathWiped] = field.wiped
+	field.stats.Births = field.dropped
+	field.wiped = 0
+	field.dropped = 0
 	field.copyCellsToNew()
 
-	if field.dropFood {
-		field.foodDropCount++
-		if field.foodDropCount > foodDropDelay {
-			field.foodDropCount = 0
-			_ = field.drop(rand.Intn(field.W), rand.Intn(field.H),
-				rand.Intn(foodDropMaxR-foodDropMinR)+foodDropMinR,
-				func(posX, posY int) {
-					field.newCells[posX][posY].foodStorage += foodDropVolume
-					if field.newCells[posX][posY].foodStorage > field.newCells[posX][posY].maxFood {
-						field.newCells[posX][posY].foodStorage = field.newCells[posX][posY].maxFood
-					}
-				})
-		}
-	}
+	field.updateFoodDrop()
+
+	field.updateDiffusion()
 
 	for i := 0; i < field.W; i++ {
 		for j := 0; j < field.H; j++ {
 			cell := &field.newCells[i][j]
+			cell.regenerate(field.turn)
 
 			if cell.entity != nil {
 				cell.entity.Update()
 				if cell.entity.IsReadyToDeath() {
+					field.stats.Deaths[cell.entity.DeathCause()]++
 					cell.Kill()
 				} else if cell.entity.IsReadyToDivide() {
 					cell.Divide()
@@ -160,6 +267,7 @@ func (field *CellField) Update() {
 			cell.updateColor()
 		}
 	}
+	field.updateMovement()
 	field.copyCellsFromNew()
 }
 
@@ -172,9 +280,10 @@ func (field *CellField) drop(x, y, r int, operation func(int, int)) error {
 		for j := y - r; j <= y+r; j++ {
 			dist := math.Sqrt(math.Pow(float64(x-i), 2) + math.Pow(float64(y-j), 2))
 			if dist <= float64(r) {
-				posX := (i + field.W) % field.W
-				posY := (j + field.H) % field.H
-				operation(posX, posY)
+				posX, posY := field.wrap(i, j)
+				if field.contains(posX, posY) {
+					operation(posX, posY)
+				}
 			}
 		}
 	}
@@ -184,17 +293,14 @@ func (field *CellField) drop(x, y, r int, operation func(int, int)) error {
 
 func (field *CellField) DropCell(x, y, r int, cellType CellType) error {
 	return field.drop(x, y, r, func(posX, posY int) {
-		field.cells[posX][posY].badConditions = cellType.Antibiotic
-		field.cells[posX][posY].foodStorage = cellType.FoodStorage
-		field.cells[posX][posY].maxFood = cellType.FoodStorage
-		field.cells[posX][posY].updateColor()
+		field.cells[posX][posY].setType(cellType)
 	})
 }
 
 func (field *CellField) DropEntity(x, y, r int, entityType EntityType) error {
-	e := NewEntityFromEntityType(entityType)
+	e := NewEntityFromEntityType(entityType, &field.resources, field.makeMutator(entityType.MutationChance, entityType.Mutation))
 	return field.drop(x, y, r, func(posX, posY int) {
-		field.putEntity(*e, posX, posX)
+		field.putEntity(*e, posX, posY)
 	})
 }
 
@@ -223,15 +329,12 @@ func (field *CellField) dropRect(x, y, w, h int, operation func(int, int)) error
 
 func (field *CellField) DropCellRect(x, y, w, h int, cellType CellType) error {
 	return field.dropRect(x, y, w, h, func(posX, posY int) {
-		field.cells[posX][posY].badConditions = cellType.Antibiotic
-		field.cells[posX][posY].foodStorage = cellType.FoodStorage
-		field.cells[posX][posY].maxFood = cellType.FoodStorage
-		field.cells[posX][posY].updateColor()
+		field.cells[posX][posY].setType(cellType)
 	})
 }
 
 func (field *CellField) DropEntityRect(x, y, w, h int, entityType EntityType) error {
-	e := NewEntityFromEntityType(entityType)
+	e := NewEntityFromEntityType(entityType, &field.resources, field.makeMutator(entityType.MutationChance, entityType.Mutation))
 	return field.dropRect(x, y, w, h, func(posX, posY int) {
 		field.putEntity(*e, posX, posY)
 	})
@@ -245,9 +348,21 @@ func NewField(w, h int) *CellField {
 }
 
 func NewFieldWithBaseCell(w, h int, base CellType) *CellField {
+	return NewFieldWithResources(w, h, base, NewResources([]CellType{base}, nil))
+}
+
+// NewFieldWithResources creates a field with the set of nutrients and stressors
+func NewFieldWithResources(w, h int, base CellType, resources Resources) *CellField {
 	field := new(CellField)
 	field.W = w
 	field.H = h
+	field.resources = resources
+	field.mutations = make([]uint64, len(resources.traitNames()))
+	_ = field.SetTopology(Topology{})
+	field.seed = time.Now().UnixNano()
+	field.source = &randomSource{}
+	field.rng = rand.New(field.source)
+	field.rng.Seed(field.seed)
 	field.cells = make([][]Cell, w)
 	field.newCells = make([][]Cell, w)
 	for i := 0; i < w; i++ {
@@ -257,10 +372,10 @@ func NewFieldWithBaseCell(w, h int, base CellType) *CellField {
 			field.cells[i][j].field = field
 			field.cells[i][j].x = i
 			field.cells[i][j].y = j
-			field.cells[i][j].foodStorage = base.FoodStorage
-			field.cells[i][j].maxFood = base.FoodStorage
-			field.cells[i][j].badConditions = base.Antibiotic
-			field.cells[i][j].updateColor()
+			field.cells[i][j].nutrients = make([]float64, len(resources.Nutrients))
+			field.cells[i][j].maxNutrients = make([]float64, len(resources.Nutrients))
+			field.cells[i][j].stressors = make([]float64, len(resources.Stressors))
+			field.cells[i][j].setType(base)
 		}
 	}
 	return field
@@ -275,6 +390,11 @@ type CellType struct {
 	Name        string
 	FoodStorage float64
 	Antibiotic  float64
+	// levels of additional nutrients and stressors by name
+	Nutrients map[string]float64
+	Stressors map[string]float64
+	// regrowth of nutrients every turn
+	Regeneration []Regeneration
 }
 
 func BaseCellType() CellType {
@@ -282,36 +402,102 @@ func BaseCellType() CellType {
 }
 
 type Cell struct {
-	entity      *Entity
-	field       *CellField
-	color       utils.Color
-	x, y        int
-	foodStorage float64
-	maxFood     float64
-	// to split in several
-	badConditions float64
+	entity *Entity
+	field  *CellField
+	color  utils.Color
+	x, y   int
+	// indexed as field resources, food and antibiotic are the first ones
+	nutrients    []float64
+	maxNutrients []float64
+	stressors    []float64
+	// shared between cells of the same type
+	regeneration []regeneration
+}
+
+func (c *Cell) setType(t CellType) {
+	nutrients, stressors := c.field.resources.cellLevels(t)
+	copy(c.nutrients, nutrients)
+	copy(c.maxNutrients, nutrients)
+	copy(c.stressors, stressors)
+	// unknown nutrients are reported by config validation
+	c.regeneration, _ = c.field.resolveRegeneration(t.Regeneration)
+	c.updateColor()
+}
+
+func nutrientRatio(value, max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	// diffusion could bring more than the cell had initially
+	return math.Min(value/max, 1)
 }
 
 func (c *Cell) updateColor() {
-	c.color.A = c.foodStorage / c.maxFood * maxCellAlpha
-	c.color.R = (c.badConditions - MinAntibiotic) / (MaxAntibiotic - MinAntibiotic)
-	c.color.G = 0.3
-	c.color.B = 0.3
+	resources := &c.field.resources
+	layer := c.field.layer
+	switch {
+	case layer > 0 && layer <= len(c.nutrients):
+		i := layer - 1
+		c.color = utils.Color{A: nutrientRatio(c.nutrients[i], c.maxNutrients[i]) * maxCellAlpha, R: 0.2, G: 0.6, B: 0.2}
+	case layer > len(c.nutrients):
+		i := layer - 1 - len(c.nutrients)
+		c.color = utils.Color{A: maxCellAlpha, R: resources.stressorRatio(i, c.stressors[i]), G: 0.3, B: 0.3}
+	default:
+		c.color.A = nutrientRatio(c.nutrients[0], c.maxNutrients[0]) * maxCellAlpha
+		c.color.R = resources.stressorRatio(0, c.stressors[0])
+		c.color.G = 0.3
+		c.color.B = 0.3
+	}
 }
 
-func (c *Cell) Feed(foodVolume float64) float64 {
-	if c.foodStorage-foodVolume < 0 {
-		volume := c.foodStorage
-		c.foodStorage = 0
+func (c *Cell) feedNutrient(i int, volume float64) float64 {
+	if c.nutrients[i]-volume < 0 {
+		volume = c.nutrients[i]
+		c.nutrients[i] = 0
 		return volume
-	} else {
-		c.foodStorage -= foodVolume
-		return foodVolume
+	}
+	c.nutrients[i] -= volume
+	return volume
+}
+
+func (c *Cell) Feed(foodVolume float64) float64 {
+	return c.feedNutrient(0, foodVolume)
+}
+
+// growthLimit returns a share of growth needs which can be satisfied after maintenance needs
+// by the scarcest nutrient, or -1 if even maintenance needs cannot be satisfied
+func (c *Cell) growthLimit(needs []float64, growth float64) float64 {
+	limit := 1.0
+	for i, need := range needs {
+		if need <= 0 || i >= len(c.nutrients) {
+			continue
+		}
+		rest := c.nutrients[i] - need
+		if rest < 0 {
+			return -1
+		}
+		if growth > 0 {
+			limit = math.Min(limit, rest/(need*growth))
+		}
+	}
+	return limit
+}
+
+func (c *Cell) consume(needs []float64, factor float64) {
+	for i, need := range needs {
+		if i < len(c.nutrients) {
+			c.feedNutrient(i, need*factor)
+		}
 	}
 }
 
 func (c *Cell) Kill() {
+	c.kill(c.field.turn)
+}
+
+func (c *Cell) kill(turn uint64) {
 	if c.entity != nil {
+		c.field.unregisterEntity(c.entity, turn)
 		c.entity.parent = nil
 		c.entity = nil
 		c.field.entityCount--
@@ -328,10 +514,10 @@ func (c *Cell) Divide() {
 
 // getters
 func (c *Cell) FoodStorage() float64 {
-	return c.foodStorage
+	return c.nutrients[0]
 }
 func (c *Cell) BadConditions() float64 {
-	return c.badConditions
+	return c.stressors[0]
 }
 
 // end of Cell
@@ -0,0 +1,102 @@
+package Cell
+
+import (
+	"errors"
+	"math"
+)
+
+// explicit scheme is stable only if the coefficient is not more than 1/4 on a square grid
+const MaxDiffusion = 0.25
+
+// Diffusion keeps coefficients of every substance, indexed as field resources
+type Diffusion struct {
+	Nutrients []float64
+	Stressors []float64
+}
+
+func (d *Diffusion) enabled() bool {
+	for _, rate := range d.Nutrients {
+		if rate > 0 {
+			return true
+		}
+	}
+	for _, rate := range d.Stressors {
+		if rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDiffusion sets diffusion coefficients by substance names, coefficients are clamped to [0, MaxDiffusion]
+func (field *CellField) SetDiffusion(rates map[string]float64) error {
+	d := Diffusion{
+		Nutrients: make([]float64, len(field.resources.Nutrients)),
+		Stressors: make([]float64, len(field.resources.Stressors)),
+	}
+	var err error
+	for name, rate := range rates {
+		rate = math.Max(0, math.Min(MaxDiffusion, rate))
+		if i := field.resources.NutrientIndex(name); i >= 0 {
+			d.Nutrients[i] = rate
+		} else if i := field.resources.StressorIndex(name); i >= 0 {
+			d.Stressors[i] = rate
+		} else {
+			err = errors.New("unknown substance " + name)
+		}
+	}
+	field.diffusion = d
+	return err
+}
+
+func (field *CellField) Diffusion() Diffusion {
+	return field.diffusion
+}
+
+// diffuse makes one step of the explicit conservative scheme: every cell exchanges
+// the substance with its nearest neighbours in proportion to the difference of levels.
+// Nothing crosses walls, absorbing edges have zero level outside.
+func (field *CellField) diffuse(rate float64, level func(c *Cell) *float64) {
+	if rate <= 0 {
+		return
+	}
+	// coefficients are given for 4 neighbours of a square grid, hex cells have 6 ones
+	rate *= 4 / float64(len(field.offsets.nearEven))
+	// cells share level slices with new cells, so old values are stored separately
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			field.diffusionBuffer[i][j] = *level(&field.newCells[i][j])
+		}
+	}
+	old := field.diffusionBuffer
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			flow := 0.0
+			for _, pos := range field.nearNeighbours(i, j) {
+				if field.contains(pos.X, pos.Y) {
+					flow += old[pos.X][pos.Y]
+				}
+				flow -= old[i][j]
+			}
+			*level(&field.newCells[i][j]) = old[i][j] + rate*flow
+		}
+	}
+}
+
+func (field *CellField) updateDiffusion() {
+	if !field.diffusion.enabled() {
+		return
+	}
+	if field.diffusionBuffer == nil {
+		field.diffusionBuffer = make([][]float64, field.W)
+		for i := range field.diffusionBuffer {
+			field.diffusionBuffer[i] = make([]float64, field.H)
+		}
+	}
+	for k, rate := range field.diffusion.Nutrients {
+		field.diffuse(rate, func(c *Cell) *float64 { return &c.nutrients[k] })
+	}
+	for k, rate := range field.diffusion.Stressors {
+		field.diffuse(rate, func(c *Cell) *float64 { return &c.stressors[k] })
+	}
+}
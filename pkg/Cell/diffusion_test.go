@@ -0,0 +1,67 @@
+package Cell
+
+import (
+	"math"
+	"testing"
+)
+
+func totalFood(field *CellField) float64 {
+	total := 0.0
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			total += field.newCells[i][j].nutrients[0]
+		}
+	}
+	return total
+}
+
+func TestDiffusionConservesMass(t *testing.T) {
+	tests := []struct {
+		neighbourhood string
+		edges         string
+		// mass is lost only behind absorbing edges
+		conserved bool
+	}{
+		{NeighbourhoodMoore, EdgesTorus, true},
+		{NeighbourhoodMoore, EdgesWall, true},
+		{NeighbourhoodVonNeumann, EdgesWall, true},
+		{NeighbourhoodHex, EdgesTorus, true},
+		{NeighbourhoodHex, EdgesWall, true},
+		{NeighbourhoodMoore, EdgesAbsorbing, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.neighbourhood+"/"+test.edges, func(t *testing.T) {
+			field := NewField(6, 6)
+			err := field.SetTopology(Topology{Neighbourhood: test.neighbourhood, Edges: test.edges})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = field.SetDiffusion(map[string]float64{FoodName: MaxDiffusion})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a spike in the corner reaches edges at once
+			field.cells[0][0].nutrients[0] += 1000
+			field.cells[3][2].nutrients[0] += 500
+			field.copyCellsToNew()
+
+			before := totalFood(field)
+			for step := 0; step < 50; step++ {
+				field.updateDiffusion()
+			}
+			after := totalFood(field)
+
+			if conserved := math.Abs(after-before) < 1e-9*before; conserved != test.conserved {
+				t.Errorf("total food %g before and %g after diffusion, expected conservation %v", before, after, test.conserved)
+			}
+			for i := 0; i < field.W; i++ {
+				for j := 0; j < field.H; j++ {
+					if field.newCells[i][j].nutrients[0] < 0 {
+						t.Fatalf("negative food %g at %d:%d", field.newCells[i][j].nutrients[0], i, j)
+					}
+				}
+			}
+		})
+	}
+}
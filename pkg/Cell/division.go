@@ -0,0 +1,87 @@
+package Cell
+
+import "cellMachine/pkg/utils"
+
+// DefaultOffspring is the number of daughters of a division if it is not specified
+const DefaultOffspring = 2
+
+// Division describes how an entity of a type divides, zero values mean the default rules
+type Division struct {
+	// entity divides when it grows to the size, maxSize by default
+	Size utils.Size
+	// number of daughters which replace the parent, 2 by default
+	Offspring int
+	// free neighbour cells which are required to divide, otherwise the entity dies of crowding.
+	// Every next daughter requires one more free cell. By default it is more than 3/8 of neighbours
+	MinFree int
+	// shares of the parent size for every daughter, daughters start with the base size if it is empty
+	Split []float64 `json:",omitempty"`
+}
+
+func (d *Division) size() utils.Size {
+	if d == nil || d.Size <= 0 {
+		return maxSize
+	}
+	return d.Size
+}
+
+func (d *Division) offspring() int {
+	if d == nil || d.Offspring <= 0 {
+		return DefaultOffspring
+	}
+	return d.Offspring
+}
+
+func (d *Division) minFree(neighbours int) int {
+	if d == nil || d.MinFree <= 0 {
+		return 3*neighbours/8 + 1
+	}
+	return d.MinFree
+}
+
+func (d *Division) daughterSize(k int, parentSize utils.Size) utils.Size {
+	if d == nil || k >= len(d.Split) {
+		return baseSize
+	}
+	return utils.Size(d.Split[k]) * parentSize
+}
+
+// Divide replaces the entity by its daughters: the first one goes to a random free neighbour cell,
+// the second one stays in the parent cell and the rest go to other free neighbour cells.
+// A single daughter stays in the parent cell.
+func (field *CellField) Divide(e Entity, x, y int) {
+	// make an array with free cells and iterate through them, cells behind absorbing edges are always free
+	emptyCells := make([]utils.Position, 0)
+	neighbours := field.neighbours(x, y)
+	for _, pos := range neighbours {
+		if !field.contains(pos.X, pos.Y) || field.newCells[pos.X][pos.Y].entity == nil {
+			emptyCells = append(emptyCells, pos)
+		}
+	}
+	emptyCount := len(emptyCells)
+	minFree := e.division.minFree(len(neighbours))
+	if emptyCount < minFree {
+		field.stats.Deaths[DeathCrowding]++
+		return
+	}
+	field.stats.Divisions++
+
+	offspring := e.division.offspring()
+	for k := 0; k < offspring && emptyCount >= minFree+k; k++ {
+		size := e.division.daughterSize(k, e.size)
+		if k == 1 || offspring == 1 {
+			field.putEntityToNew(e, size, x, y)
+			continue
+		}
+		n := field.rng.Intn(len(emptyCells))
+		pos := emptyCells[n]
+		emptyCells = append(emptyCells[:n], emptyCells[n+1:]...)
+		if field.contains(pos.X, pos.Y) {
+			field.putEntityToNew(e, size, pos.X, pos.Y)
+		} else {
+			// the daughter is born and absorbed at once
+			field.stats.Births++
+			field.stats.Deaths[DeathAbsorbed]++
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package Cell
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDivisionPopulationInvariant(t *testing.T) {
+	topologies := []struct {
+		name string
+		w, h int
+		Topology
+	}{
+		{"moore torus", 8, 8, Topology{}},
+		{"hex wall", 8, 8, Topology{Neighbourhood: NeighbourhoodHex, Edges: EdgesWall}},
+		{"von neumann absorbing", 6, 6, Topology{Neighbourhood: NeighbourhoodVonNeumann, Edges: EdgesAbsorbing}},
+		{"small torus with large radius", 3, 3, Topology{Radius: 3}},
+	}
+
+	for _, topology := range topologies {
+		for _, offspring := range []int{1, 2, 3, 5} {
+			t.Run(fmt.Sprintf("%s/%d offspring", topology.name, offspring), func(t *testing.T) {
+				field := NewField(topology.w, topology.h)
+				field.SetSeed(1)
+				err := field.SetTopology(topology.Topology)
+				if err != nil {
+					t.Fatal(err)
+				}
+				field.EnableLineage()
+				entityType := EntityType{
+					Name: "bug", Resistance: 10, GrownRateBase: 0.5, ConsumptionBase: 1,
+					Division: &Division{Offspring: offspring, MinFree: 1, Split: []float64{0.5}},
+				}
+				err = field.DropEntityRect(1, 1, 2, 2, entityType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				field.FinishSeeding()
+
+				var divisions uint64
+				population := field.EntityCount()
+				for turn := 1; turn <= 40; turn++ {
+					field.Update()
+					stats := field.Stats()
+					change := int64(stats.Births) - int64(stats.Divisions)
+					for _, deaths := range stats.Deaths {
+						change -= int64(deaths)
+					}
+					if int64(stats.Population)-int64(population) != change {
+						t.Fatalf("turn %d: population changed from %d to %d, stats give %+d",
+							turn, population, stats.Population, change)
+					}
+					if live := liveRecords(field); live != stats.Population {
+						t.Fatalf("turn %d: got %d live lineage records for %d entities", turn, live, stats.Population)
+					}
+					population = stats.Population
+					divisions += stats.Divisions
+				}
+				if divisions == 0 {
+					t.Error("entities did not divide")
+				}
+			})
+		}
+	}
+}
+
+func liveRecords(field *CellField) uint64 {
+	var live uint64
+	for _, record := range field.Lineage().Records() {
+		if !record.Dead {
+			live++
+		}
+	}
+	return live
+}
+
+func TestSingleDaughterStays(t *testing.T) {
+	field := NewField(3, 3)
+	field.SetSeed(1)
+	field.EnableLineage()
+	err := field.DropEntityRect(1, 1, 1, 1, EntityType{
+		Name: "bug", Resistance: 10, GrownRateBase: 0.5, ConsumptionBase: 1,
+		Division: &Division{Offspring: 1, Split: []float64{0.5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field.FinishSeeding()
+	parent := field.cells[1][1].entity.lineage.ID
+
+	for turn := 1; turn <= 40; turn++ {
+		field.Update()
+		stats := field.Stats()
+		if field.EntityCount() != 1 || field.cells[1][1].entity == nil {
+			t.Fatalf("turn %d: the daughter left the parent cell", turn)
+		}
+		if stats.Divisions > 0 {
+			if stats.Births != 1 {
+				t.Fatalf("turn %d: got %d births for a single daughter", turn, stats.Births)
+			}
+			// the daughter replaces its parent
+			if lineage := field.cells[1][1].entity.lineage; lineage.ParentID != parent || lineage.Generation != 1 {
+				t.Errorf("got daughter %+v, expected a child of %d", lineage, parent)
+			}
+			return
+		}
+	}
+	t.Error("entity did not divide")
+}
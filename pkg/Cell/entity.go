@@ -2,6 +2,7 @@ package Cell
 
 import (
 	"cellMachine/pkg/utils"
+	"math"
 	"math/rand"
 )
 
@@ -19,29 +20,17 @@ const (
 	borderGrownRate   = 1.0
 )
 
-var MutationCounter uint64
-
-type Mutator struct {
-	mutationChance float64
-}
-
-func newMutator() Mutator {
-	return Mutator{mutationChance: baseMutationChance}
-}
-
-func (m *Mutator) MutateFloat64(num float64) float64 {
-	dice := rand.Float64()
-	if dice <= m.mutationChance {
-		factor := rand.Float64()/10.0 + 0.95 // from 0.95 to 1.05
-		num *= factor
-		MutationCounter++
-	}
-	return num
-}
+// indexes of basic traits in order of Resources.geneNames
+const (
+	traitResistance = iota
+	traitGrownRate
+	traitConsumption
+)
 
 type EntityState struct {
 	isReadyToDivide bool
 	isReadyToDeath  bool
+	deathCause      DeathCause
 }
 
 // for json unmarshalling
@@ -51,14 +40,43 @@ type EntityType struct {
 	Resistance      float64
 	GrownRateBase   float64
 	MutationChance  float64
+	// consumption of additional nutrients and resistance to additional stressors by name
+	Needs       map[string]float64
+	Resistances map[string]float64
+	// entities of the type can move if it is specified
+	Motility *Motility
+	// default division rules are used if it is not specified
+	Division *Division
+	// every trait mutates with MutationChance by the uniform factor from 0.95 to 1.05 if it is not specified
+	Mutation *Mutation
+	// genes which replace the traits above or add new traits
+	Genes []Gene `json:",omitempty"`
+	// costs of trait values
+	TradeOffs []TradeOff `json:",omitempty"`
+	// entities die after MaxAge turns if it is not zero
+	MaxAge uint64
+	// baseline chance to die every turn
+	DeathProbability float64
 }
 
 type Entity struct {
-	// basic
-	consumptionBase float64 // expected not more than 100
-	resistance      float64 // expected not more than 100
-	grownRateBase   float64 // less than 1.0
-	mutator         Mutator
+	genome genome
+	// phenotype expressed from the genome, indexed as field genes
+	traits []float64
+	// basic, indexed as field resources
+	needs         []float64 // food consumption is expected not more than 100
+	resistances   []float64 // antibiotic resistance is expected not more than 100
+	grownRateBase float64   // less than 1.0
+	mutator       Mutator
+	lineage       Lineage
+	motility      *motility
+	division      *Division
+	tradeOffs     []tradeOff
+	costs         entityCosts
+	// turns lived
+	age              uint64
+	maxAge           uint64
+	deathProbability float64
 	// volatile
 	color  utils.Color
 	size   utils.Size
@@ -68,28 +86,81 @@ type Entity struct {
 
 func (e *Entity) calculateColor() {
 	e.color.A = 0.8
-	e.color.R = e.resistance / borderResistance
+	e.color.R = e.resistance(0) / borderResistance
 	e.color.G = e.grownRateBase / borderGrownRate
-	e.color.B = e.consumptionBase / borderConsumption
+	e.color.B = e.need(0) / borderConsumption
+}
+
+func (e *Entity) need(i int) float64 {
+	if i < len(e.needs) {
+		return e.needs[i]
+	}
+	return 0
+}
+
+func (e *Entity) resistance(i int) float64 {
+	if i < len(e.resistances) {
+		return e.resistances[i]
+	}
+	return 0
+}
+
+// vitality is defined by the most harmful stressor in the cell
+func (e *Entity) vitality() float64 {
+	vitality := 1.0
+	for i, level := range e.parent.stressors {
+		if level <= 0 {
+			continue
+		}
+		resistance := e.resistance(i)
+		if resistance <= 0 {
+			return 0
+		}
+		vitality = math.Min(vitality, (resistance-level)/resistance)
+	}
+	return vitality
+}
+
+func (e *Entity) die(cause DeathCause) {
+	e.state.isReadyToDeath = true
+	e.state.deathCause = cause
 }
 
 func (e *Entity) Update() {
-	vitality := (e.resistance - e.parent.BadConditions()) / e.resistance
+	e.age++
+	if e.maxAge > 0 && e.age > e.maxAge {
+		e.die(DeathOldAge)
+		return
+	}
+
+	vitality := e.vitality()
 	if vitality <= 0 {
-		e.state.isReadyToDeath = true
+		e.die(DeathAntibiotic)
 		return
 	}
 
-	grownRate := e.grownRateBase*vitality + 1
-	consumptionVolume := grownRate * e.consumptionBase
-	// isn't enough food in the cell
-	if e.parent.Feed(consumptionVolume) < consumptionVolume {
-		e.state.isReadyToDeath = true
+	if e.deathProbability > 0 && e.parent.field.rng.Float64() < e.deathProbability {
+		e.die(DeathRandom)
+		return
+	}
+	if e.costs.deathChance > 0 && e.parent.field.rng.Float64() < e.costs.deathChance {
+		e.die(DeathTradeOff)
 		return
 	}
 
+	grownRate := e.grownRateBase*vitality*e.costs.growthFactor + 1
+	// base needs are required to survive, growth is limited by the scarcest nutrient
+	limit := e.parent.growthLimit(e.costs.demands, grownRate-1)
+	if limit < 0 {
+		e.parent.consume(e.costs.demands, 1)
+		e.die(DeathStarvation)
+		return
+	}
+	grownRate = (grownRate-1)*limit + 1
+	e.parent.consume(e.costs.demands, grownRate)
+
 	e.size *= utils.Size(grownRate)
-	if e.size >= maxSize {
+	if e.size >= e.division.size() {
 		e.state.isReadyToDivide = true
 		return
 	}
@@ -113,43 +184,78 @@ func (e *Entity) IsReadyToDivide() bool {
 func (e *Entity) IsReadyToDeath() bool {
 	return e.state.isReadyToDeath
 }
+func (e *Entity) DeathCause() DeathCause {
+	return e.state.deathCause
+}
+
+// in order of Resources.traitNames
+func (e *Entity) traitValues() []float64 {
+	values := append([]float64(nil), e.traits...)
+	return append(values, e.mutator.mutationChance)
+}
+
+// express sets the phenotype of the genome, needs and resistances are taken from traits
+// of nutrients and stressors
+func (e *Entity) express(nutrients, stressors int) {
+	e.traits = e.genome.express()
+	e.grownRateBase = e.traits[traitGrownRate]
+	e.needs = e.traits[traitConsumption : traitConsumption+nutrients]
+	e.resistances = make([]float64, stressors)
+	e.resistances[0] = e.traits[traitResistance]
+	copy(e.resistances[1:], e.traits[traitConsumption+nutrients:])
+	e.calculateCosts()
+}
+
+func (e *Entity) Lineage() Lineage {
+	return e.lineage
+}
 
 func (e *Entity) SetParent(c *Cell) {
 	e.parent = c
 }
 
-func NewEntity() *Entity {
+func NewEntity(rng *rand.Rand) *Entity {
 	entity := new(Entity)
 	entity.size = baseSize
-	entity.resistance = baseResistance
-	entity.grownRateBase = baseGrownRateBase
-	entity.consumptionBase = baseConsumptionBase
-	entity.mutator = newMutator()
+	entity.genome.alleles = [][]float64{{baseResistance}, {baseGrownRateBase}, {baseConsumptionBase}}
+	entity.express(1, 1)
+	entity.mutator = newMutator(rng)
 	entity.calculateColor()
-	entity.state = EntityState{false, false}
+	entity.state = EntityState{}
 	return entity
 }
 
 func NewEntityFromEntity(entity Entity) *Entity {
 	e := new(Entity)
 	e.mutator = entity.mutator
+	e.lineage.TypeName = entity.lineage.TypeName
+	e.motility = entity.motility
+	e.division = entity.division
+	e.tradeOffs = entity.tradeOffs
+	e.maxAge = entity.maxAge
+	e.deathProbability = entity.deathProbability
 	e.size = baseSize
-	e.grownRateBase = e.mutator.MutateFloat64(entity.grownRateBase)
-	e.resistance = e.mutator.MutateFloat64(entity.resistance)
-	e.consumptionBase = e.mutator.MutateFloat64(entity.consumptionBase)
+	e.genome = entity.genome.replicate(&e.mutator)
+	e.mutator.mutateChance(len(entity.traits))
+	e.express(len(entity.needs), len(entity.resistances))
 	e.calculateColor()
-	e.state = EntityState{false, false}
+	e.state = EntityState{}
 	return e
 }
 
-func NewEntityFromEntityType(base EntityType) *Entity {
+func NewEntityFromEntityType(base EntityType, resources *Resources, mutator Mutator) *Entity {
 	e := new(Entity)
-	e.mutator = Mutator{mutationChance: base.MutationChance}
+	e.mutator = mutator
+	e.lineage.TypeName = base.Name
 	e.size = baseSize
-	e.grownRateBase = base.GrownRateBase
-	e.resistance = base.Resistance
-	e.consumptionBase = base.ConsumptionBase
+	e.tradeOffs = resources.resolveTradeOffs(base.TradeOffs)
+	e.maxAge = base.MaxAge
+	e.deathProbability = base.DeathProbability
+	e.genome = resources.newGenome(base)
+	e.express(len(resources.Nutrients), len(resources.Stressors))
+	e.motility = resources.resolveMotility(base.Motility)
+	e.division = base.Division
 	e.calculateColor()
-	e.state = EntityState{false, false}
+	e.state = EntityState{}
 	return e
 }
@@ -0,0 +1,73 @@
+package Cell
+
+import "testing"
+
+// singleEntityField makes a field with an entity of the type in the center
+func singleEntityField(t *testing.T, entityType EntityType) *CellField {
+	field := NewFieldWithBaseCell(3, 3, CellType{Name: "plain", FoodStorage: 10, Antibiotic: 1})
+	field.SetSeed(1)
+	err := field.DropEntityRect(1, 1, 1, 1, entityType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	field.FinishSeeding()
+	return field
+}
+
+func TestEntityDeathCauses(t *testing.T) {
+	tests := []struct {
+		name       string
+		entityType EntityType
+		cause      DeathCause
+		// turn of the death
+		turn int
+	}{
+		{"old age", EntityType{Name: "bug", Resistance: 10, ConsumptionBase: 1, MaxAge: 3}, DeathOldAge, 4},
+		{"random", EntityType{Name: "bug", Resistance: 10, ConsumptionBase: 1, DeathProbability: 1}, DeathRandom, 1},
+		{"antibiotic", EntityType{Name: "bug", ConsumptionBase: 1}, DeathAntibiotic, 1},
+		{"starvation", EntityType{Name: "bug", Resistance: 10, ConsumptionBase: 20}, DeathStarvation, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := singleEntityField(t, test.entityType)
+			for turn := 1; turn <= test.turn; turn++ {
+				field.Update()
+				if alive := field.EntityCount() > 0; alive != (turn < test.turn) {
+					t.Fatalf("turn %d: got alive %v, expected death at turn %d", turn, alive, test.turn)
+				}
+			}
+			stats := field.Stats()
+			for cause, deaths := range stats.Deaths {
+				expected := uint64(0)
+				if DeathCause(cause) == test.cause {
+					expected = 1
+				}
+				if deaths != expected {
+					t.Errorf("got %d deaths of %s, expected %d", deaths, DeathCause(cause), expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDaughterAge(t *testing.T) {
+	field := singleEntityField(t, EntityType{
+		Name: "bug", Resistance: 10, GrownRateBase: 0.5, ConsumptionBase: 1,
+		Division: &Division{Offspring: 1},
+	})
+	for turn := 1; turn <= 40; turn++ {
+		field.Update()
+		e := field.cells[1][1].entity
+		if field.Stats().Divisions > 0 {
+			if e.age != 0 {
+				t.Errorf("got daughter age %d, expected 0", e.age)
+			}
+			return
+		}
+		if e.age != uint64(turn) {
+			t.Fatalf("turn %d: got age %d", turn, e.age)
+		}
+	}
+	t.Error("entity did not divide")
+}
@@ -0,0 +1,118 @@
+package Cell
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EventFoodDrop is a kind of event which is logged on every random food drop
+const EventFoodDrop = "food_drop"
+
+// Event is something which happened on the field during a turn besides lives of entities
+type Event struct {
+	Kind    string
+	X, Y, R int
+	Volume  float64 `json:",omitempty"`
+	// name of a stamped cell type
+	TypeName string `json:",omitempty"`
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("%s x=%d y=%d r=%d", e.Kind, e.X, e.Y, e.R)
+	if e.TypeName != "" {
+		return s + " type=" + e.TypeName
+	}
+	return s + fmt.Sprintf(" volume=%g", e.Volume)
+}
+
+// FoodDrop describes random food drops in shape of circles
+type FoodDrop struct {
+	// a drop happens every Interval turns, or with Probability on every turn if Interval is zero
+	Interval    uint32
+	Probability float64
+	Volume      float64
+	// radius is chosen from [MinR, MaxR]
+	MinR, MaxR int
+	// center of a drop is chosen inside the region, whole field if not specified
+	Region *Region
+	// food is not limited by the initial cell storage
+	ExceedMax bool
+	// cells of a drop are replaced by this type instead of adding food if it is specified
+	Stamp *CellType `json:",omitempty"`
+}
+
+// DefaultFoodDrop is the food drop which is enabled by the DropFood flag
+func DefaultFoodDrop() FoodDrop {
+	return FoodDrop{
+		Interval: foodDropDelay + 1,
+		Volume:   foodDropVolume,
+		MinR:     foodDropMinR,
+		MaxR:     foodDropMaxR - 1,
+	}
+}
+
+// SetFoodDrop enables random food drops, nil disables them
+func (field *CellField) SetFoodDrop(drop *FoodDrop) error {
+	if drop != nil {
+		if drop.MinR < 0 || drop.MaxR < drop.MinR {
+			return errors.New("invalid food drop radius range")
+		}
+		if _, _, _, _, err := field.clip(drop.Region); err != nil {
+			return err
+		}
+	}
+	field.foodDrop = drop
+	field.foodDropCount = 0
+	return nil
+}
+
+func (field *CellField) FoodDrop() *FoodDrop {
+	return field.foodDrop
+}
+
+func (field *CellField) isFoodDropTime() bool {
+	drop := field.foodDrop
+	if drop.Interval > 0 {
+		field.foodDropCount++
+		if field.foodDropCount < drop.Interval {
+			return false
+		}
+		field.foodDropCount = 0
+		return true
+	}
+	return field.rng.Float64() < drop.Probability
+}
+
+// updateFoodDrop drops food to new cells if it is time to do it
+func (field *CellField) updateFoodDrop() {
+	if field.foodDrop == nil || !field.isFoodDropTime() {
+		return
+	}
+	drop := field.foodDrop
+	x0, y0, x1, y1, _ := field.clip(drop.Region)
+	r := drop.MinR
+	x := x0 + field.rng.Intn(x1-x0)
+	y := y0 + field.rng.Intn(y1-y0)
+	if drop.MaxR > drop.MinR {
+		r += field.rng.Intn(drop.MaxR - drop.MinR + 1)
+	}
+
+	event := Event{Kind: EventFoodDrop, X: x, Y: y, R: r, Volume: drop.Volume}
+	if drop.Stamp != nil {
+		event.TypeName = drop.Stamp.Name
+		event.Volume = 0
+	}
+	field.stats.Events = append(field.stats.Events, event)
+
+	_ = field.drop(x, y, r, func(posX, posY int) {
+		c := &field.newCells[posX][posY]
+		if drop.Stamp != nil {
+			c.setType(*drop.Stamp)
+			return
+		}
+		c.nutrients[0] += drop.Volume
+		if !drop.ExceedMax && c.nutrients[0] > c.maxNutrients[0] {
+			c.nutrients[0] = c.maxNutrients[0]
+		}
+	})
+}
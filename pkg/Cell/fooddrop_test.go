@@ -0,0 +1,93 @@
+package Cell
+
+import "testing"
+
+// dropFood runs food drops of the number of turns and returns their events
+func dropFood(field *CellField, turns int) []Event {
+	var events []Event
+	for turn := 0; turn < turns; turn++ {
+		field.stats = FieldStats{}
+		field.copyCellsToNew()
+		field.updateFoodDrop()
+		field.copyCellsFromNew()
+		events = append(events, field.stats.Events...)
+	}
+	return events
+}
+
+func TestFoodDropTiming(t *testing.T) {
+	tests := []struct {
+		name  string
+		drop  FoodDrop
+		drops int
+	}{
+		{"interval", FoodDrop{Interval: 3, Volume: 1}, 4},
+		{"every turn", FoodDrop{Interval: 1, Volume: 1}, 12},
+		{"certain probability", FoodDrop{Probability: 1, Volume: 1}, 12},
+		{"zero probability", FoodDrop{Volume: 1}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := NewField(5, 5)
+			field.SetSeed(1)
+			drop := test.drop
+			err := field.SetFoodDrop(&drop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if events := dropFood(field, 12); len(events) != test.drops {
+				t.Errorf("got %d drops in 12 turns, expected %d", len(events), test.drops)
+			}
+		})
+	}
+}
+
+func TestFoodDropVolume(t *testing.T) {
+	stamp := CellType{Name: "rich", FoodStorage: 50, Antibiotic: 2}
+	tests := []struct {
+		name      string
+		exceedMax bool
+		stamp     *CellType
+		event     Event
+		// food in the center and in a cell next to it after a drop
+		food, max float64
+	}{
+		{"capped", false, nil, Event{Kind: EventFoodDrop, X: 2, Y: 3, R: 1, Volume: 5}, 10, 10},
+		{"exceeding max", true, nil, Event{Kind: EventFoodDrop, X: 2, Y: 3, R: 1, Volume: 5}, 15, 10},
+		{"stamp", false, &stamp, Event{Kind: EventFoodDrop, X: 2, Y: 3, R: 1, TypeName: "rich"}, 50, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := NewFieldWithBaseCell(6, 6, CellType{Name: "plain", FoodStorage: 10})
+			field.SetSeed(1)
+			err := field.SetFoodDrop(&FoodDrop{
+				Interval:  1,
+				Volume:    5,
+				MinR:      1,
+				MaxR:      1,
+				Region:    &Region{X: 2, Y: 3, W: 1, H: 1},
+				ExceedMax: test.exceedMax,
+				Stamp:     test.stamp,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := dropFood(field, 1)
+			if len(events) != 1 || events[0] != test.event {
+				t.Fatalf("got events %v, expected %v", events, test.event)
+			}
+			if food := field.cells[2][3].nutrients[0]; food != test.food {
+				t.Errorf("got food %g in the center, expected %g", food, test.food)
+			}
+			if max := field.cells[2][3].maxNutrients[0]; max != test.max {
+				t.Errorf("got max food %g in the center, expected %g", max, test.max)
+			}
+			// the cell is out of the drop circle
+			if food := field.cells[5][0].nutrients[0]; food != 10 {
+				t.Errorf("got food %g out of the drop, expected 10", food)
+			}
+		})
+	}
+}
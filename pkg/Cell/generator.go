@@ -0,0 +1,229 @@
+package Cell
+
+import (
+	"errors"
+	"math"
+)
+
+// kinds of procedural generators
+const (
+	GeneratorLinear       = "linear"
+	GeneratorRadial       = "radial"
+	GeneratorNoise        = "noise"
+	GeneratorStripes      = "stripes"
+	GeneratorCheckerboard = "checkerboard"
+)
+
+var GeneratorKinds = []string{GeneratorLinear, GeneratorRadial, GeneratorNoise, GeneratorStripes, GeneratorCheckerboard}
+
+// Generator sets a level of a nutrient or a stressor in every cell of the region
+// by a procedural pattern with values from From to To
+type Generator struct {
+	Kind      string
+	Substance string
+	From, To  float64
+	// start point of a linear gradient or center of a radial one
+	X, Y int
+	// end point of a linear gradient
+	X2, Y2 int
+	// radius of a radial gradient
+	R int
+	// width of stripes, size of checkerboard squares or noise scale
+	Size int
+	// direction of stripes in degrees
+	Angle float64
+	// number of noise layers, every next one has doubled frequency and halved amplitude
+	Octaves int
+	// number of discrete levels, zero means a smooth pattern
+	Steps int
+	// whole field if not specified
+	Region *Region
+}
+
+// shape returns a position in the pattern from 0 to 1 for the cell
+func (g *Generator) shape(field *CellField) (func(x, y int) float64, error) {
+	switch g.Kind {
+	case GeneratorLinear:
+		dx, dy := float64(g.X2-g.X), float64(g.Y2-g.Y)
+		length := dx*dx + dy*dy
+		if length == 0 {
+			return nil, errors.New("linear gradient needs different start and end points")
+		}
+		return func(x, y int) float64 {
+			return (float64(x-g.X)*dx + float64(y-g.Y)*dy) / length
+		}, nil
+	case GeneratorRadial:
+		if g.R <= 0 {
+			return nil, errors.New("radial gradient needs positive radius")
+		}
+		return func(x, y int) float64 {
+			return math.Hypot(float64(x-g.X), float64(y-g.Y)) / float64(g.R)
+		}, nil
+	case GeneratorNoise:
+		if g.Size <= 0 {
+			return nil, errors.New("noise needs positive size")
+		}
+		noise := newPerlinNoise(field)
+		octaves := g.Octaves
+		if octaves < 1 {
+			octaves = 1
+		}
+		return func(x, y int) float64 {
+			value, amplitude, scale := 0.0, 1.0, float64(g.Size)
+			for i := 0; i < octaves; i++ {
+				value += amplitude * noise.at(float64(x)/scale, float64(y)/scale)
+				amplitude /= 2
+				scale /= 2
+			}
+			return value
+		}, nil
+	case GeneratorStripes:
+		if g.Size <= 0 {
+			return nil, errors.New("stripes need positive size")
+		}
+		sin, cos := math.Sincos(g.Angle * math.Pi / 180)
+		return func(x, y int) float64 {
+			band := int(math.Floor((float64(x)*cos + float64(y)*sin) / float64(g.Size)))
+			return float64(band & 1)
+		}, nil
+	case GeneratorCheckerboard:
+		if g.Size <= 0 {
+			return nil, errors.New("checkerboard needs positive size")
+		}
+		return func(x, y int) float64 {
+			return float64((x/g.Size + y/g.Size) & 1)
+		}, nil
+	}
+	return nil, errors.New("unknown generator kind " + g.Kind)
+}
+
+// Generate applies the generator to the field between turns
+func (field *CellField) Generate(g Generator) error {
+	nutrient := field.resources.NutrientIndex(g.Substance)
+	stressor := field.resources.StressorIndex(g.Substance)
+	if nutrient < 0 && stressor < 0 {
+		return errors.New("unknown substance " + g.Substance)
+	}
+	shape, err := g.shape(field)
+	if err != nil {
+		return err
+	}
+
+	x0, y0, x1, y1, err := field.clip(g.Region)
+	if err != nil {
+		return err
+	}
+
+	positions := make([][]float64, x1-x0)
+	min, max := math.Inf(1), math.Inf(-1)
+	for i := range positions {
+		positions[i] = make([]float64, y1-y0)
+		for j := range positions[i] {
+			t := shape(x0+i, y0+j)
+			positions[i][j] = t
+			min, max = math.Min(min, t), math.Max(max, t)
+		}
+	}
+	// noise has no fixed range, so it is stretched to the whole range of values
+	if g.Kind == GeneratorNoise && max > min {
+		for i := range positions {
+			for j := range positions[i] {
+				positions[i][j] = (positions[i][j] - min) / (max - min)
+			}
+		}
+	}
+
+	for i := range positions {
+		for j := range positions[i] {
+			t := math.Max(0, math.Min(1, positions[i][j]))
+			if g.Steps > 1 {
+				t = math.Min(math.Floor(t*float64(g.Steps)), float64(g.Steps-1)) / float64(g.Steps-1)
+			}
+			value := g.From + (g.To-g.From)*t
+			c := &field.cells[x0+i][y0+j]
+			if nutrient >= 0 {
+				c.nutrients[nutrient] = value
+				c.maxNutrients[nutrient] = value
+			} else {
+				c.stressors[stressor] = value
+			}
+		}
+	}
+
+	if stressor >= 0 {
+		field.updateStressorRange(stressor)
+	}
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			field.cells[i][j].updateColor()
+		}
+	}
+	return nil
+}
+
+// updateStressorRange extends the range of the stressor for coloring by levels on the field
+func (field *CellField) updateStressorRange(k int) {
+	r := &field.resources
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			level := field.cells[i][j].stressors[k]
+			r.MinStressors[k] = math.Min(r.MinStressors[k], level)
+			r.MaxStressors[k] = math.Max(r.MaxStressors[k], level)
+		}
+	}
+}
+
+// perlinNoise is a classic gradient noise with the permutation taken from the field random source
+type perlinNoise struct {
+	perm [512]int
+}
+
+func newPerlinNoise(field *CellField) *perlinNoise {
+	noise := new(perlinNoise)
+	p := field.rng.Perm(256)
+	for i := range noise.perm {
+		noise.perm[i] = p[i&255]
+	}
+	return noise
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+func gradient(hash int, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return x - y
+	case 2:
+		return -x + y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+func (noise *perlinNoise) at(x, y float64) float64 {
+	xi, yi := int(math.Floor(x))&255, int(math.Floor(y))&255
+	x -= math.Floor(x)
+	y -= math.Floor(y)
+	u, v := fade(x), fade(y)
+	p := &noise.perm
+	a, b := p[xi]+yi, p[xi+1]+yi
+	return lerp(v,
+		lerp(u, gradient(p[a], x, y), gradient(p[b], x-1, y)),
+		lerp(u, gradient(p[a+1], x, y-1), gradient(p[b+1], x-1, y-1)))
+}
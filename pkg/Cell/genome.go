@@ -0,0 +1,137 @@
+package Cell
+
+import "math"
+
+// rules of allele expression
+const (
+	// mean of alleles
+	ExpressionAdditive = "additive"
+	// the highest allele
+	ExpressionDominant = "dominant"
+	// the lowest allele
+	ExpressionRecessive = "recessive"
+)
+
+var Expressions = []string{ExpressionAdditive, ExpressionDominant, ExpressionRecessive}
+
+// Gene describes a gene of an entity type. Genes named as traits (e.g. "resistance" or
+// "consumption.nitrogen") replace values of the type, genes with other names add new traits.
+type Gene struct {
+	Name string
+	// initial values of alleles, one for a haploid gene or two for a diploid one,
+	// a single allele with the value of the type trait by default
+	Alleles []float64 `json:",omitempty"`
+	// additive by default
+	Expression string `json:",omitempty"`
+	// the expressed value is added to other traits with weights, e.g. {"resistance": -0.5}
+	Effects map[string]float64 `json:",omitempty"`
+}
+
+type geneEffect struct {
+	from, to int
+	weight   float64
+}
+
+// genomeRules are genes of a type resolved by field genes
+type genomeRules struct {
+	genes       []Gene
+	expressions []string
+	effects     []geneEffect
+}
+
+func (r *Resources) resolveGenes(genes []Gene) *genomeRules {
+	if len(genes) == 0 {
+		return nil
+	}
+	names := r.geneNames()
+	rules := &genomeRules{genes: genes, expressions: make([]string, len(names))}
+	for _, gene := range genes {
+		from := indexOf(names, gene.Name)
+		if from < 0 {
+			continue
+		}
+		rules.expressions[from] = gene.Expression
+		for name, weight := range gene.Effects {
+			if to := indexOf(names, name); to >= 0 {
+				rules.effects = append(rules.effects, geneEffect{from: from, to: to, weight: weight})
+			}
+		}
+	}
+	return rules
+}
+
+// genome keeps alleles of every gene, indexed as field genes
+type genome struct {
+	alleles [][]float64
+	rules   *genomeRules
+}
+
+func (r *Resources) newGenome(t EntityType) genome {
+	g := genome{rules: r.resolveGenes(t.Genes)}
+	for _, value := range r.entityTraits(t) {
+		g.alleles = append(g.alleles, []float64{value})
+	}
+	names := r.geneNames()
+	for _, gene := range t.Genes {
+		if i := indexOf(names, gene.Name); i >= 0 && len(gene.Alleles) > 0 {
+			g.alleles[i] = append([]float64(nil), gene.Alleles...)
+		}
+	}
+	return g
+}
+
+func (g genome) expression(i int) string {
+	if g.rules == nil {
+		return ExpressionAdditive
+	}
+	return g.rules.expressions[i]
+}
+
+func expressAlleles(alleles []float64, expression string) float64 {
+	value := alleles[0]
+	for _, allele := range alleles[1:] {
+		switch expression {
+		case ExpressionDominant:
+			value = math.Max(value, allele)
+		case ExpressionRecessive:
+			value = math.Min(value, allele)
+		default:
+			value += allele
+		}
+	}
+	if expression == ExpressionDominant || expression == ExpressionRecessive {
+		return value
+	}
+	return value / float64(len(alleles))
+}
+
+// express maps the genotype to values of traits
+func (g genome) express() []float64 {
+	expressed := make([]float64, len(g.alleles))
+	for i, alleles := range g.alleles {
+		expressed[i] = expressAlleles(alleles, g.expression(i))
+	}
+	if g.rules == nil {
+		return expressed
+	}
+	traits := append([]float64(nil), expressed...)
+	for _, effect := range g.rules.effects {
+		traits[effect.to] += effect.weight * expressed[effect.from]
+	}
+	for i := range traits {
+		traits[i] = math.Max(traits[i], 0)
+	}
+	return traits
+}
+
+// replicate copies the genome for offspring, every allele could mutate
+func (g genome) replicate(m *Mutator) genome {
+	copied := genome{alleles: make([][]float64, len(g.alleles)), rules: g.rules}
+	for i, alleles := range g.alleles {
+		copied.alleles[i] = make([]float64, len(alleles))
+		for k, allele := range alleles {
+			copied.alleles[i][k] = m.MutateTrait(i, allele)
+		}
+	}
+	return copied
+}
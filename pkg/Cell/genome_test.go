@@ -0,0 +1,69 @@
+package Cell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpressAlleles(t *testing.T) {
+	tests := []struct {
+		expression string
+		alleles    []float64
+		value      float64
+	}{
+		{ExpressionAdditive, []float64{3}, 3},
+		{ExpressionAdditive, []float64{2, 5}, 3.5},
+		{"", []float64{2, 5}, 3.5},
+		{ExpressionDominant, []float64{3}, 3},
+		{ExpressionDominant, []float64{2, 5}, 5},
+		{ExpressionDominant, []float64{5, 2}, 5},
+		{ExpressionRecessive, []float64{3}, 3},
+		{ExpressionRecessive, []float64{2, 5}, 2},
+		{ExpressionRecessive, []float64{5, 2}, 2},
+	}
+
+	for _, test := range tests {
+		if value := expressAlleles(test.alleles, test.expression); value != test.value {
+			t.Errorf("%q expression of %v: got %g, expected %g", test.expression, test.alleles, value, test.value)
+		}
+	}
+}
+
+func TestGenomeExpress(t *testing.T) {
+	resources := Resources{Nutrients: []string{FoodName}, Stressors: []string{AntibioticName}, Genes: []string{"efflux"}}
+	base := EntityType{Resistance: 5, GrownRateBase: 0.5, ConsumptionBase: 1}
+	tests := []struct {
+		name  string
+		genes []Gene
+		// resistance, grownRateBase, consumptionBase, efflux
+		traits []float64
+	}{
+		{"type values", nil, []float64{5, 0.5, 1, 0}},
+		{
+			"diploid trait",
+			[]Gene{{Name: "resistance", Alleles: []float64{4, 8}, Expression: ExpressionRecessive}},
+			[]float64{4, 0.5, 1, 0},
+		},
+		{
+			"effects",
+			[]Gene{{Name: "efflux", Alleles: []float64{2, 0}, Expression: ExpressionDominant, Effects: map[string]float64{"resistance": 1.5, "consumptionBase": 0.5}}},
+			[]float64{8, 0.5, 2, 2},
+		},
+		{
+			"traits are not negative",
+			[]Gene{{Name: "efflux", Alleles: []float64{1}, Effects: map[string]float64{"grownRateBase": -1}}},
+			[]float64{5, 0, 1, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entityType := base
+			entityType.Genes = test.genes
+			traits := resources.newGenome(entityType).express()
+			if !reflect.DeepEqual(traits, test.traits) {
+				t.Errorf("got traits %v, expected %v", traits, test.traits)
+			}
+		})
+	}
+}
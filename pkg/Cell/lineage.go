@@ -0,0 +1,152 @@
+package Cell
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Lineage is an identity of an entity and its place in the family tree
+type Lineage struct {
+	ID       uint64
+	ParentID uint64 // zero for initial entities
+	TypeName string // type of the initial ancestor
+	// initial entities are the generation zero
+	Generation uint64
+	BirthTurn  uint64
+}
+
+type LineageRecord struct {
+	Lineage
+	BirthX, BirthY int
+	Dead           bool
+	DeathTurn      uint64 // meaningful only if the entity is dead
+}
+
+// LineageLog keeps records of every entity which was ever born on the field
+type LineageLog struct {
+	records []LineageRecord
+	index   map[uint64]int
+}
+
+func newLineageLog() *LineageLog {
+	return &LineageLog{index: make(map[uint64]int)}
+}
+
+func (log *LineageLog) born(lineage Lineage, x, y int) {
+	log.index[lineage.ID] = len(log.records)
+	log.records = append(log.records, LineageRecord{Lineage: lineage, BirthX: x, BirthY: y})
+}
+
+func (log *LineageLog) died(id uint64, turn uint64) {
+	if i, ok := log.index[id]; ok {
+		log.records[i].Dead = true
+		log.records[i].DeathTurn = turn
+	}
+}
+
+func (log *LineageLog) Records() []LineageRecord {
+	return log.records
+}
+
+func (log *LineageLog) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(log.records)
+}
+
+// newick labels cannot contain spaces and punctuation of the format
+func newickLabel(record *LineageRecord) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" \t()[]:;,'", r) {
+			return '_'
+		}
+		return r
+	}, record.TypeName)
+	return name + "_" + strconv.FormatUint(record.ID, 10)
+}
+
+// WriteNewick writes the family tree in Newick format. Branch lengths are measured in turns.
+// Entities whose parents are unknown (initial ones or born before tracking) are the roots.
+func (log *LineageLog) WriteNewick(w io.Writer) error {
+	children := make(map[uint64][]int)
+	roots := make([]int, 0)
+	for i := range log.records {
+		parentID := log.records[i].ParentID
+		if _, ok := log.index[parentID]; ok && parentID != 0 {
+			children[parentID] = append(children[parentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	buffer := bufio.NewWriter(w)
+	var writeNode func(i int, parentBirth uint64)
+	writeNode = func(i int, parentBirth uint64) {
+		record := &log.records[i]
+		if nodes := children[record.ID]; len(nodes) > 0 {
+			buffer.WriteByte('(')
+			for k, child := range nodes {
+				if k > 0 {
+					buffer.WriteByte(',')
+				}
+				writeNode(child, record.BirthTurn)
+			}
+			buffer.WriteByte(')')
+		}
+		buffer.WriteString(newickLabel(record))
+		buffer.WriteByte(':')
+		buffer.WriteString(strconv.FormatUint(record.BirthTurn-parentBirth, 10))
+	}
+
+	buffer.WriteByte('(')
+	for k, root := range roots {
+		if k > 0 {
+			buffer.WriteByte(',')
+		}
+		writeNode(root, 0)
+	}
+	buffer.WriteString(");\n")
+	return buffer.Flush()
+}
+
+// EnableLineage starts logging of births and deaths. Living entities are logged immediately.
+func (field *CellField) EnableLineage() {
+	if field.lineage != nil {
+		return
+	}
+	field.lineage = newLineageLog()
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			if e := field.cells[i][j].entity; e != nil {
+				field.lineage.born(e.lineage, i, j)
+			}
+		}
+	}
+}
+
+// Lineage returns nil if lineage logging is disabled
+func (field *CellField) Lineage() *LineageLog {
+	return field.lineage
+}
+
+// Entities are born and die with the turn of the update. Entities which are dropped or killed
+// between turns (by events or painting) are stamped with the next turn, as they are the part of it.
+func (field *CellField) registerEntity(e *Entity, parentID, generation, turn uint64, x, y int) {
+	field.nextID++
+	e.lineage.ID = field.nextID
+	e.lineage.ParentID = parentID
+	e.lineage.Generation = generation
+	e.lineage.BirthTurn = turn
+	if field.lineage != nil {
+		field.lineage.born(e.lineage, x, y)
+	}
+}
+
+func (field *CellField) unregisterEntity(e *Entity, turn uint64) {
+	if field.lineage != nil {
+		field.lineage.died(e.lineage.ID, turn)
+	}
+}
@@ -0,0 +1,123 @@
+package Cell
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteNewick(t *testing.T) {
+	tests := []struct {
+		name     string
+		lineages []Lineage
+		newick   string
+	}{
+		{"empty", nil, "();\n"},
+		{
+			"single root",
+			[]Lineage{{ID: 1, TypeName: "bug", BirthTurn: 4}},
+			"(bug_1:4);\n",
+		},
+		{
+			"family",
+			[]Lineage{
+				{ID: 1, TypeName: "bug"},
+				{ID: 2, ParentID: 1, TypeName: "bug", Generation: 1, BirthTurn: 3},
+				{ID: 3, ParentID: 1, TypeName: "bug", Generation: 1, BirthTurn: 3},
+				{ID: 4, ParentID: 2, TypeName: "bug", Generation: 2, BirthTurn: 5},
+				{ID: 5, TypeName: "weird type(x)", BirthTurn: 2},
+			},
+			"(((bug_4:2)bug_2:3,bug_3:3)bug_1:0,weird_type_x__5:2);\n",
+		},
+		{
+			// the parent was born before tracking was enabled
+			"unknown parent",
+			[]Lineage{
+				{ID: 7, ParentID: 6, TypeName: "bug", Generation: 3, BirthTurn: 10},
+				{ID: 8, ParentID: 7, TypeName: "bug", Generation: 4, BirthTurn: 12},
+			},
+			"((bug_8:2)bug_7:10);\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := newLineageLog()
+			for _, lineage := range test.lineages {
+				log.born(lineage, 0, 0)
+			}
+			var builder strings.Builder
+			err := log.WriteNewick(&builder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if builder.String() != test.newick {
+				t.Errorf("got %q, expected %q", builder.String(), test.newick)
+			}
+		})
+	}
+}
+
+func TestLineageDeath(t *testing.T) {
+	log := newLineageLog()
+	log.born(Lineage{ID: 1}, 0, 0)
+	log.born(Lineage{ID: 2, ParentID: 1}, 1, 0)
+	log.died(1, 0)
+
+	records := log.Records()
+	if !records[0].Dead || records[0].DeathTurn != 0 {
+		t.Errorf("entity died at turn 0, got %+v", records[0])
+	}
+	if records[1].Dead {
+		t.Errorf("entity is alive, got %+v", records[1])
+	}
+}
+
+func TestLineageSnapshot(t *testing.T) {
+	field := NewField(8, 8)
+	field.SetSeed(1)
+	field.EnableLineage()
+	err := field.DropEntityRect(3, 3, 2, 2, EntityType{Name: "bug", Resistance: 10, GrownRateBase: 0.5, ConsumptionBase: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for turn := 0; turn < 30; turn++ {
+		field.Update()
+	}
+	if len(field.Lineage().Records()) <= 4 {
+		t.Fatal("entities did not divide")
+	}
+
+	bytes, err := json.Marshal(field.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot FieldSnapshot
+	err = json.Unmarshal(bytes, &snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewFieldFromSnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Lineage() == nil {
+		t.Fatal("lineage tracking is disabled after restoring")
+	}
+	if !reflect.DeepEqual(restored.Lineage().Records(), field.Lineage().Records()) {
+		t.Error("lineage records differ after restoring")
+	}
+
+	// both fields go on the same way
+	for turn := 0; turn < 10; turn++ {
+		field.Update()
+		restored.Update()
+	}
+	var original, resumed strings.Builder
+	_ = field.Lineage().WriteNewick(&original)
+	_ = restored.Lineage().WriteNewick(&resumed)
+	if original.String() != resumed.String() {
+		t.Errorf("got tree %q after restoring, expected %q", resumed.String(), original.String())
+	}
+}
@@ -0,0 +1,144 @@
+package Cell
+
+import "cellMachine/pkg/utils"
+
+// rules of entity movement
+const (
+	MoveRandom     = "random"
+	MoveChemotaxis = "chemotaxis"
+)
+
+var MoveRules = []string{MoveRandom, MoveChemotaxis}
+
+// Motility allows an entity to move to a free neighbour cell
+type Motility struct {
+	// chance to move on every turn
+	Probability float64
+	// food which is spent from the current cell for a move, entity stays if there is not enough food
+	Cost float64
+	// random walk (by default) or chemotaxis: moving towards the highest level
+	// of a nutrient or the lowest level of a stressor among free neighbour cells
+	Rule string
+	// food by default
+	Substance string
+}
+
+// motility of an entity with the substance resolved by field resources
+type motility struct {
+	Motility
+	nutrient int
+	stressor int
+}
+
+func (r *Resources) resolveMotility(m *Motility) *motility {
+	if m == nil {
+		return nil
+	}
+	resolved := &motility{Motility: *m, nutrient: -1, stressor: -1}
+	if m.Rule == MoveChemotaxis {
+		name := m.Substance
+		if name == "" {
+			name = FoodName
+		}
+		resolved.nutrient = r.NutrientIndex(name)
+		resolved.stressor = r.StressorIndex(name)
+	}
+	return resolved
+}
+
+// attraction of the cell for chemotaxis, higher is better
+func (m *motility) attraction(c *Cell) float64 {
+	if m.nutrient >= 0 {
+		return c.nutrients[m.nutrient]
+	}
+	if m.stressor >= 0 {
+		return -c.stressors[m.stressor]
+	}
+	return 0
+}
+
+// chooseTarget returns a free neighbour cell which the entity wants to move to,
+// random walk could lead it behind an absorbing edge
+func (field *CellField) chooseTarget(c *Cell, m *motility) (utils.Position, bool) {
+	free := make([]utils.Position, 0)
+	for _, pos := range field.neighbours(c.x, c.y) {
+		if !field.contains(pos.X, pos.Y) || field.newCells[pos.X][pos.Y].entity == nil {
+			free = append(free, pos)
+		}
+	}
+	if len(free) == 0 {
+		return utils.Position{}, false
+	}
+	if m.Rule != MoveChemotaxis {
+		return free[field.rng.Intn(len(free))], true
+	}
+
+	// the best cells are chosen randomly, entity stays if the current cell is not worse
+	best := make([]utils.Position, 0)
+	bestAttraction := m.attraction(c)
+	for _, pos := range free {
+		if !field.contains(pos.X, pos.Y) {
+			continue
+		}
+		attraction := m.attraction(&field.newCells[pos.X][pos.Y])
+		if attraction > bestAttraction {
+			bestAttraction = attraction
+			best = best[:0]
+		}
+		if attraction == bestAttraction && attraction > m.attraction(c) {
+			best = append(best, pos)
+		}
+	}
+	if len(best) == 0 {
+		return utils.Position{}, false
+	}
+	return best[field.rng.Intn(len(best))], true
+}
+
+// updateMovement moves entities in two phases: every entity chooses a target cell
+// which is free after divisions, then one of entities is chosen randomly
+// for every target, so there are no conflicts in new cells
+func (field *CellField) updateMovement() {
+	targets := make(map[utils.Position][]utils.Position)
+	order := make([]utils.Position, 0)
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			c := &field.newCells[i][j]
+			e := c.entity
+			// daughters rest in the turn of their birth
+			if e == nil || e.motility == nil || e.lineage.ParentID != 0 && e.lineage.BirthTurn == field.turn {
+				continue
+			}
+			if c.nutrients[0] < e.motility.Cost || field.rng.Float64() >= e.motility.Probability {
+				continue
+			}
+			target, ok := field.chooseTarget(c, e.motility)
+			if !ok {
+				continue
+			}
+			if _, ok := targets[target]; !ok {
+				order = append(order, target)
+			}
+			targets[target] = append(targets[target], utils.Position{X: i, Y: j})
+		}
+	}
+
+	for _, target := range order {
+		candidates := targets[target]
+		if !field.contains(target.X, target.Y) {
+			for _, from := range candidates {
+				field.newCells[from.X][from.Y].Kill()
+				field.stats.Deaths[DeathAbsorbed]++
+			}
+			continue
+		}
+		from := candidates[field.rng.Intn(len(candidates))]
+		src := &field.newCells[from.X][from.Y]
+		dst := &field.newCells[target.X][target.Y]
+		src.Feed(src.entity.motility.Cost)
+		dst.entity = src.entity
+		dst.entity.SetParent(dst)
+		src.entity = nil
+		field.stats.Moves++
+	}
+}
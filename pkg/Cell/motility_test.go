@@ -0,0 +1,139 @@
+package Cell
+
+import (
+	"cellMachine/pkg/utils"
+	"testing"
+)
+
+// movingField makes a field with entities which always move if they can
+func movingField(t *testing.T, w, h int, edges, rule string, positions ...utils.Position) *CellField {
+	field := NewFieldWithBaseCell(w, h, CellType{Name: "plain", FoodStorage: 10, Antibiotic: 1})
+	field.SetSeed(1)
+	err := field.SetTopology(Topology{Edges: edges})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entityType := EntityType{Name: "bug", Resistance: 10, Motility: &Motility{Probability: 1, Cost: 2, Rule: rule}}
+	for _, pos := range positions {
+		err = field.DropEntityRect(pos.X, pos.Y, 1, 1, entityType)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	field.FinishSeeding()
+	return field
+}
+
+// move runs a movement step of a turn without other updates
+func move(field *CellField) {
+	field.turn++
+	field.stats = FieldStats{}
+	field.copyCellsToNew()
+	field.updateMovement()
+	field.copyCellsFromNew()
+}
+
+func TestChemotaxis(t *testing.T) {
+	tests := []struct {
+		name string
+		// changes levels of the field before a move
+		prepare func(field *CellField)
+		target  utils.Position
+		moves   uint64
+	}{
+		{
+			"towards food",
+			func(field *CellField) { field.cells[3][1].nutrients[0] = 20 },
+			utils.Position{X: 3, Y: 1}, 1,
+		},
+		{
+			"the current cell is the best",
+			func(field *CellField) { field.cells[2][2].nutrients[0] = 20 },
+			utils.Position{X: 2, Y: 2}, 0,
+		},
+		{
+			"not enough food to pay the cost",
+			func(field *CellField) {
+				field.cells[2][2].nutrients[0] = 1
+				field.cells[3][1].nutrients[0] = 20
+			},
+			utils.Position{X: 2, Y: 2}, 0,
+		},
+		{
+			"daughter rests in the turn of birth",
+			func(field *CellField) {
+				field.cells[3][1].nutrients[0] = 20
+				e := field.cells[2][2].entity
+				e.lineage.ParentID = 1
+				e.lineage.BirthTurn = field.turn + 1
+			},
+			utils.Position{X: 2, Y: 2}, 0,
+		},
+		{
+			"occupied cell",
+			func(field *CellField) {
+				field.cells[3][1].nutrients[0] = 20
+				field.cells[1][3].nutrients[0] = 15
+				field.cells[3][1].entity = NewEntityFromEntity(*field.cells[2][2].entity)
+				field.cells[3][1].entity.SetParent(&field.cells[3][1])
+			},
+			utils.Position{X: 1, Y: 3}, 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := movingField(t, 5, 5, EdgesWall, MoveChemotaxis, utils.Position{X: 2, Y: 2})
+			test.prepare(field)
+			food := field.cells[2][2].nutrients[0]
+			move(field)
+			if field.cells[test.target.X][test.target.Y].entity == nil {
+				t.Fatalf("no entity at %v", test.target)
+			}
+			if moves := field.Stats().Moves; moves != test.moves {
+				t.Fatalf("got %d moves, expected %d", moves, test.moves)
+			}
+			// the cost is paid from the cell which entity leaves
+			if test.moves > 0 && field.cells[2][2].nutrients[0] != food-2 {
+				t.Errorf("got food %g in the left cell, expected %g", field.cells[2][2].nutrients[0], food-2)
+			}
+		})
+	}
+}
+
+func TestChemotaxisFromStressor(t *testing.T) {
+	field := movingField(t, 3, 3, EdgesWall, MoveChemotaxis, utils.Position{X: 1, Y: 1})
+	field.cells[1][1].entity.motility = field.resources.resolveMotility(&Motility{Probability: 1, Rule: MoveChemotaxis, Substance: AntibioticName})
+	field.cells[0][2].stressors[0] = 0
+	move(field)
+	if field.cells[0][2].entity == nil {
+		t.Error("entity did not move to the lowest stressor level")
+	}
+}
+
+func TestMovementConflict(t *testing.T) {
+	// both entities want the middle cell, only one gets it
+	field := movingField(t, 3, 1, EdgesWall, MoveChemotaxis, utils.Position{X: 0, Y: 0}, utils.Position{X: 2, Y: 0})
+	field.cells[1][0].nutrients[0] = 20
+	move(field)
+	if field.cells[1][0].entity == nil {
+		t.Fatal("no entity in the middle cell")
+	}
+	if field.Stats().Moves != 1 || field.EntityCount() != 2 {
+		t.Errorf("got %d moves and %d entities, expected 1 and 2", field.Stats().Moves, field.EntityCount())
+	}
+	if field.cells[0][0].entity == nil && field.cells[2][0].entity == nil {
+		t.Error("both entities left their cells")
+	}
+}
+
+func TestAbsorbingEdgeMovement(t *testing.T) {
+	// every neighbour of a single cell is behind the edge
+	field := movingField(t, 1, 1, EdgesAbsorbing, MoveRandom, utils.Position{X: 0, Y: 0})
+	move(field)
+	stats := field.Stats()
+	if stats.Deaths[DeathAbsorbed] != 1 || field.EntityCount() != 0 || field.cells[0][0].entity != nil {
+		t.Errorf("got %d absorbed and %d entities, expected the entity to be absorbed",
+			stats.Deaths[DeathAbsorbed], field.EntityCount())
+	}
+}
@@ -0,0 +1,170 @@
+package Cell
+
+import (
+	"math"
+	"math/rand"
+)
+
+// distributions of mutation steps
+const (
+	// value is multiplied by a factor from [1 - Step, 1 + Step]
+	StepUniform = "uniform"
+	// normally distributed addition with standard deviation Step
+	StepGaussian = "gaussian"
+	// value is multiplied by exp(N(0, Step))
+	StepLogNormal = "log-normal"
+)
+
+var StepDistributions = []string{StepUniform, StepGaussian, StepLogNormal}
+
+const (
+	defaultMutationStep = 0.05
+	// the mutation chance is a trait too, it evolves only if there is a rule for it
+	MutationChanceTrait = "mutationChance"
+)
+
+// MutationRule describes mutations of a trait, missing values mean the defaults
+type MutationRule struct {
+	// chance to mutate during division, the mutation chance of the entity by default
+	Probability *float64 `json:",omitempty"`
+	// uniform by default
+	Distribution string
+	// 0.05 by default
+	Step *float64 `json:",omitempty"`
+	// value is clamped to [Min, Max], from 0 without an upper bound by default
+	Min *float64 `json:",omitempty"`
+	Max *float64 `json:",omitempty"`
+}
+
+// Mutation is a mutation model of an entity type
+type Mutation struct {
+	// rule for all traits
+	MutationRule
+	// rules by trait names as in metrics, e.g. "resistance" or "consumption.nitrogen"
+	Traits map[string]MutationRule `json:",omitempty"`
+}
+
+// rule of the trait with defaults taken from the common rule
+func (m *Mutation) rule(name string) MutationRule {
+	rule := m.Traits[name]
+	if rule.Probability == nil {
+		rule.Probability = m.Probability
+	}
+	if rule.Distribution == "" {
+		rule.Distribution = m.Distribution
+	}
+	if rule.Step == nil {
+		rule.Step = m.Step
+	}
+	if rule.Min == nil {
+		rule.Min = m.Min
+	}
+	if rule.Max == nil {
+		rule.Max = m.Max
+	}
+	return rule
+}
+
+// mutation model with rules indexed as traits
+type mutationModel struct {
+	*Mutation
+	rules []MutationRule
+	// the mutation chance has a rule
+	evolvable bool
+}
+
+func (r *Resources) resolveMutation(m *Mutation) *mutationModel {
+	if m == nil {
+		return nil
+	}
+	names := r.traitNames()
+	model := &mutationModel{Mutation: m, rules: make([]MutationRule, len(names))}
+	for i, name := range names {
+		model.rules[i] = m.rule(name)
+	}
+	_, model.evolvable = m.Traits[MutationChanceTrait]
+	return model
+}
+
+type Mutator struct {
+	mutationChance float64
+	model          *mutationModel
+	// mutations of every trait, shared by all entities of the field
+	counters []uint64
+	rng      *rand.Rand
+}
+
+func newMutator(rng *rand.Rand) Mutator {
+	return Mutator{mutationChance: baseMutationChance, rng: rng}
+}
+
+func (field *CellField) makeMutator(mutationChance float64, mutation *Mutation) Mutator {
+	return Mutator{
+		mutationChance: mutationChance,
+		model:          field.resources.resolveMutation(mutation),
+		counters:       field.mutations,
+		rng:            field.rng,
+	}
+}
+
+func (m *Mutator) rule(trait int) MutationRule {
+	if m.model == nil {
+		return MutationRule{}
+	}
+	return m.model.rules[trait]
+}
+
+// MutateTrait returns a value of the trait for offspring
+func (m *Mutator) MutateTrait(trait int, num float64) float64 {
+	rule := m.rule(trait)
+	chance := m.mutationChance
+	if rule.Probability != nil {
+		chance = *rule.Probability
+	}
+	dice := m.rng.Float64()
+	if dice > chance {
+		return num
+	}
+	step := defaultMutationStep
+	if rule.Step != nil {
+		step = *rule.Step
+	}
+	switch rule.Distribution {
+	case StepGaussian:
+		num += m.rng.NormFloat64() * step
+	case StepLogNormal:
+		num *= math.Exp(m.rng.NormFloat64() * step)
+	default:
+		// from 1 - step to 1 + step
+		num *= m.rng.Float64()/(0.5/step) + (1 - step)
+	}
+	min := 0.0
+	if rule.Min != nil {
+		min = *rule.Min
+	}
+	num = math.Max(num, min)
+	if rule.Max != nil {
+		num = math.Min(num, *rule.Max)
+	}
+	if m.counters != nil {
+		m.counters[trait]++
+	}
+	return num
+}
+
+// mutateChance changes the mutation chance of offspring if it is evolvable
+func (m *Mutator) mutateChance(trait int) {
+	if m.model == nil || !m.model.evolvable {
+		return
+	}
+	m.mutationChance = math.Min(m.MutateTrait(trait, m.mutationChance), 1)
+}
+
+// Mutations returns the total number of mutations since the start
+func (field *CellField) Mutations() uint64 {
+	total := uint64(0)
+	for _, count := range field.mutations {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,83 @@
+package Cell
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func value(v float64) *float64 {
+	return &v
+}
+
+func TestMutateTraitClamping(t *testing.T) {
+	tests := []struct {
+		name string
+		rule MutationRule
+		// every value is in [low, high] and both bounds are reached
+		low, high float64
+		mutations uint64
+	}{
+		{"gaussian", MutationRule{Distribution: StepGaussian, Step: value(10), Min: value(1), Max: value(6)}, 1, 6, 1000},
+		{"log-normal without max", MutationRule{Distribution: StepLogNormal, Step: value(3), Min: value(2)}, 2, math.Inf(1), 1000},
+		{"uniform", MutationRule{Distribution: StepUniform, Step: value(0.5), Min: value(2.8), Max: value(3.2)}, 2.8, 3.2, 1000},
+		{"zero max", MutationRule{Distribution: StepGaussian, Step: value(10), Max: value(0)}, 0, 0, 1000},
+		{"zero step", MutationRule{Distribution: StepGaussian, Step: value(0)}, 3, 3, 1000},
+		{"no mutations", MutationRule{Probability: value(0), Step: value(10), Min: value(1), Max: value(6)}, 3, 3, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := Mutator{
+				mutationChance: 1,
+				model:          &mutationModel{rules: []MutationRule{test.rule}},
+				counters:       make([]uint64, 1),
+				rng:            rand.New(rand.NewSource(1)),
+			}
+			low, high := math.Inf(1), math.Inf(-1)
+			for k := 0; k < 1000; k++ {
+				value := m.MutateTrait(0, 3)
+				low, high = math.Min(low, value), math.Max(high, value)
+			}
+			if low != test.low {
+				t.Errorf("got the lowest value %g, expected %g", low, test.low)
+			}
+			if high > test.high || !math.IsInf(test.high, 1) && high != test.high {
+				t.Errorf("got the highest value %g, expected %g", high, test.high)
+			}
+			if m.counters[0] != test.mutations {
+				t.Errorf("got %d mutations, expected %d", m.counters[0], test.mutations)
+			}
+		})
+	}
+}
+
+func TestMutationRuleDefaults(t *testing.T) {
+	m := Mutation{
+		MutationRule: MutationRule{Distribution: StepGaussian, Step: value(0.3), Min: value(2), Max: value(5)},
+		Traits: map[string]MutationRule{
+			// zero values override the common rule too
+			"resistance":    {Step: value(0), Min: value(0)},
+			"grownRateBase": {Probability: value(0.5), Max: value(4)},
+		},
+	}
+	tests := []struct {
+		trait          string
+		probability    *float64
+		step, min, max float64
+	}{
+		{"resistance", nil, 0, 0, 5},
+		{"grownRateBase", value(0.5), 0.3, 2, 4},
+		{"consumptionBase", nil, 0.3, 2, 5},
+	}
+
+	for _, test := range tests {
+		rule := m.rule(test.trait)
+		if !reflect.DeepEqual(rule.Probability, test.probability) || rule.Distribution != StepGaussian ||
+			*rule.Step != test.step || *rule.Min != test.min || *rule.Max != test.max {
+			t.Errorf("%s: got probability %v, step %g, min %g, max %g, expected %v, %g, %g, %g", test.trait,
+				rule.Probability, *rule.Step, *rule.Min, *rule.Max, test.probability, test.step, test.min, test.max)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package Cell
+
+// randomSource is a splitmix64 generator. Unlike the default source of math/rand
+// its whole state is a single number, so it can be stored in a snapshot.
+type randomSource struct {
+	state uint64
+}
+
+func (s *randomSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (s *randomSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *randomSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
@@ -0,0 +1,85 @@
+package Cell
+
+import (
+	"errors"
+	"math"
+)
+
+// models of food regeneration
+const (
+	RegenerationConstant = "constant"
+	RegenerationLogistic = "logistic"
+	RegenerationSeasonal = "seasonal"
+)
+
+var RegenerationModels = []string{RegenerationConstant, RegenerationLogistic, RegenerationSeasonal}
+
+// Regeneration describes how a nutrient of a cell grows back every turn,
+// it never exceeds the initial cell storage
+type Regeneration struct {
+	Model string
+	// food by default
+	Nutrient string
+	// constant and seasonal models add Rate per turn, logistic one grows
+	// by Rate * level * (1 - level / max)
+	Rate float64
+	// seasonal supply is Rate * (1 + Amplitude * sin(2 * Pi * (turn + Phase) / Period))
+	Period    float64
+	Amplitude float64
+	Phase     float64
+}
+
+// regeneration of a cell with the nutrient index resolved by field resources
+type regeneration struct {
+	Regeneration
+	nutrient int
+}
+
+func (field *CellField) resolveRegeneration(models []Regeneration) ([]regeneration, error) {
+	if len(models) == 0 {
+		return nil, nil
+	}
+	resolved := make([]regeneration, 0, len(models))
+	for _, m := range models {
+		name := m.Nutrient
+		if name == "" {
+			name = FoodName
+		}
+		i := field.resources.NutrientIndex(name)
+		if i < 0 {
+			return resolved, errors.New("unknown nutrient " + name)
+		}
+		resolved = append(resolved, regeneration{Regeneration: m, nutrient: i})
+	}
+	return resolved, nil
+}
+
+func (r *regeneration) supply(level, max float64, turn uint64) float64 {
+	switch r.Model {
+	case RegenerationConstant:
+		return r.Rate
+	case RegenerationLogistic:
+		if max <= 0 {
+			return 0
+		}
+		return r.Rate * level * (1 - level/max)
+	case RegenerationSeasonal:
+		if r.Period <= 0 {
+			return r.Rate
+		}
+		season := math.Sin(2 * math.Pi * (float64(turn) + r.Phase) / r.Period)
+		return math.Max(0, r.Rate*(1+r.Amplitude*season))
+	}
+	return 0
+}
+
+func (c *Cell) regenerate(turn uint64) {
+	for i := range c.regeneration {
+		r := &c.regeneration[i]
+		k := r.nutrient
+		if c.nutrients[k] >= c.maxNutrients[k] {
+			continue
+		}
+		c.nutrients[k] = math.Min(c.maxNutrients[k], c.nutrients[k]+r.supply(c.nutrients[k], c.maxNutrients[k], turn))
+	}
+}
@@ -0,0 +1,64 @@
+package Cell
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegenerationSupply(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      Regeneration
+		level, max float64
+		turn       uint64
+		supply     float64
+	}{
+		{"constant", Regeneration{Model: RegenerationConstant, Rate: 2}, 5, 10, 1, 2},
+		{"logistic", Regeneration{Model: RegenerationLogistic, Rate: 0.5}, 4, 10, 1, 1.2},
+		{"logistic on empty cell", Regeneration{Model: RegenerationLogistic, Rate: 0.5}, 0, 10, 1, 0},
+		{"logistic without storage", Regeneration{Model: RegenerationLogistic, Rate: 0.5}, 0, 0, 1, 0},
+		{"seasonal peak", Regeneration{Model: RegenerationSeasonal, Rate: 2, Period: 8, Amplitude: 0.5}, 5, 10, 2, 3},
+		{"seasonal shifted peak", Regeneration{Model: RegenerationSeasonal, Rate: 2, Period: 8, Amplitude: 0.5, Phase: 1}, 5, 10, 1, 3},
+		// supply never goes below zero in a deep trough
+		{"seasonal trough", Regeneration{Model: RegenerationSeasonal, Rate: 2, Period: 8, Amplitude: 3}, 5, 10, 6, 0},
+		{"seasonal without period", Regeneration{Model: RegenerationSeasonal, Rate: 2, Amplitude: 0.5}, 5, 10, 2, 2},
+		{"unknown", Regeneration{Model: "rain", Rate: 2}, 5, 10, 1, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := regeneration{Regeneration: test.model}
+			if supply := r.supply(test.level, test.max, test.turn); math.Abs(supply-test.supply) > 1e-9 {
+				t.Errorf("got supply %g, expected %g", supply, test.supply)
+			}
+		})
+	}
+}
+
+func TestRegenerate(t *testing.T) {
+	cellType := CellType{
+		Name:        "meadow",
+		FoodStorage: 10,
+		Nutrients:   map[string]float64{"iron": 4},
+		Regeneration: []Regeneration{
+			{Model: RegenerationConstant, Rate: 3},
+			{Model: RegenerationConstant, Nutrient: "iron", Rate: 1},
+		},
+	}
+	resources := NewResources([]CellType{cellType}, nil)
+	field := NewFieldWithResources(1, 1, cellType, resources)
+	iron := resources.NutrientIndex("iron")
+	c := &field.cells[0][0]
+	c.nutrients[0] = 5
+	c.nutrients[iron] = 0
+
+	expected := [][2]float64{{8, 1}, {10, 2}, {10, 3}, {10, 4}, {10, 4}}
+	for turn, levels := range expected {
+		c.regenerate(uint64(turn + 1))
+		// levels grow back up to the initial storage
+		if c.nutrients[0] != levels[0] || c.nutrients[iron] != levels[1] {
+			t.Errorf("turn %d: got food %g and iron %g, expected %g and %g", turn+1,
+				c.nutrients[0], c.nutrients[iron], levels[0], levels[1])
+		}
+	}
+}
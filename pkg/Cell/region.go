@@ -0,0 +1,116 @@
+package Cell
+
+import "errors"
+
+// Region is a rectangle on the field
+type Region struct {
+	X, Y, W, H int
+}
+
+// clip returns bounds of the region inside the field, nil region is the whole field
+func (field *CellField) clip(region *Region) (x0, y0, x1, y1 int, err error) {
+	x0, y0, x1, y1 = 0, 0, field.W, field.H
+	if region != nil {
+		if region.X > x0 {
+			x0 = region.X
+		}
+		if region.Y > y0 {
+			y0 = region.Y
+		}
+		if region.X+region.W < x1 {
+			x1 = region.X + region.W
+		}
+		if region.Y+region.H < y1 {
+			y1 = region.Y + region.H
+		}
+	}
+	if x0 >= x1 || y0 >= y1 {
+		return 0, 0, 0, 0, errors.New("region is out of the field")
+	}
+	return x0, y0, x1, y1, nil
+}
+
+// AddNutrient changes a level of the nutrient in the region between turns,
+// the level never goes below zero and it could exceed the initial one
+func (field *CellField) AddNutrient(region *Region, name string, volume float64) error {
+	k := field.resources.NutrientIndex(name)
+	if k < 0 {
+		return errors.New("unknown nutrient " + name)
+	}
+	x0, y0, x1, y1, err := field.clip(region)
+	if err != nil {
+		return err
+	}
+	for i := x0; i < x1; i++ {
+		for j := y0; j < y1; j++ {
+			c := &field.cells[i][j]
+			c.nutrients[k] += volume
+			if c.nutrients[k] < 0 {
+				c.nutrients[k] = 0
+			}
+			if c.nutrients[k] > c.maxNutrients[k] {
+				c.maxNutrients[k] = c.nutrients[k]
+			}
+			c.updateColor()
+		}
+	}
+	return nil
+}
+
+// AddStressor changes a level of the stressor in the region between turns,
+// the level never goes below zero
+func (field *CellField) AddStressor(region *Region, name string, volume float64) error {
+	k := field.resources.StressorIndex(name)
+	if k < 0 {
+		return errors.New("unknown stressor " + name)
+	}
+	x0, y0, x1, y1, err := field.clip(region)
+	if err != nil {
+		return err
+	}
+	for i := x0; i < x1; i++ {
+		for j := y0; j < y1; j++ {
+			c := &field.cells[i][j]
+			c.stressors[k] += volume
+			if c.stressors[k] < 0 {
+				c.stressors[k] = 0
+			}
+		}
+	}
+	field.updateStressorRange(k)
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			field.cells[i][j].updateColor()
+		}
+	}
+	return nil
+}
+
+// Inoculate puts entities of the type to every cell of the region between turns
+func (field *CellField) Inoculate(region *Region, entityType EntityType) error {
+	x0, y0, x1, y1, err := field.clip(region)
+	if err != nil {
+		return err
+	}
+	return field.DropEntityRect(x0, y0, x1-x0, y1-y0, entityType)
+}
+
+// Wipe kills all entities in the region between turns, they are counted
+// as wiped in stats of the next turn
+func (field *CellField) Wipe(region *Region) (uint64, error) {
+	x0, y0, x1, y1, err := field.clip(region)
+	if err != nil {
+		return 0, err
+	}
+	var killed uint64
+	for i := x0; i < x1; i++ {
+		for j := y0; j < y1; j++ {
+			if field.cells[i][j].entity != nil {
+				field.cells[i][j].kill(field.turn + 1)
+				killed++
+			}
+		}
+	}
+	field.wiped += killed
+	return killed, nil
+}
@@ -0,0 +1,158 @@
+package Cell
+
+import "sort"
+
+const (
+	FoodName       = "food"
+	AntibioticName = "antibiotic"
+)
+
+// Resources is a registry of nutrients and stressors which are present on the field.
+// Food is always the first nutrient and antibiotic is always the first stressor,
+// cells and entities store their levels and needs in the same order.
+type Resources struct {
+	Nutrients []string
+	Stressors []string
+	// ranges of stressor levels for color calculation
+	MinStressors []float64
+	MaxStressors []float64
+	// additional genes of entities besides traits of nutrients and stressors
+	Genes []string `json:",omitempty"`
+}
+
+func sortedKeys(names map[string]bool) []string {
+	keys := make([]string, 0, len(names))
+	for name := range names {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NewResources collects names of all nutrients, stressors and genes mentioned by the types
+func NewResources(cellTypes []CellType, entityTypes []EntityType) Resources {
+	nutrients := make(map[string]bool)
+	stressors := make(map[string]bool)
+	for _, t := range cellTypes {
+		for name := range t.Nutrients {
+			nutrients[name] = true
+		}
+		for name := range t.Stressors {
+			stressors[name] = true
+		}
+	}
+	for _, t := range entityTypes {
+		for name := range t.Needs {
+			nutrients[name] = true
+		}
+		for name := range t.Resistances {
+			stressors[name] = true
+		}
+	}
+	delete(nutrients, FoodName)
+	delete(stressors, AntibioticName)
+
+	r := Resources{
+		Nutrients: append([]string{FoodName}, sortedKeys(nutrients)...),
+		Stressors: append([]string{AntibioticName}, sortedKeys(stressors)...),
+	}
+	builtin := make(map[string]bool)
+	for _, name := range r.geneNames() {
+		builtin[name] = true
+	}
+	genes := make(map[string]bool)
+	for _, t := range entityTypes {
+		for _, gene := range t.Genes {
+			if !builtin[gene.Name] && gene.Name != MutationChanceTrait {
+				genes[gene.Name] = true
+			}
+		}
+	}
+	r.Genes = sortedKeys(genes)
+
+	r.MinStressors = make([]float64, len(r.Stressors))
+	r.MaxStressors = make([]float64, len(r.Stressors))
+	for i, t := range cellTypes {
+		_, levels := r.cellLevels(t)
+		for k, level := range levels {
+			if i == 0 || level < r.MinStressors[k] {
+				r.MinStressors[k] = level
+			}
+			if i == 0 || level > r.MaxStressors[k] {
+				r.MaxStressors[k] = level
+			}
+		}
+	}
+	return r
+}
+
+func indexOf(names []string, name string) int {
+	for i := range names {
+		if names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// NutrientIndex returns -1 for unknown nutrients
+func (r *Resources) NutrientIndex(name string) int {
+	return indexOf(r.Nutrients, name)
+}
+
+// StressorIndex returns -1 for unknown stressors
+func (r *Resources) StressorIndex(name string) int {
+	return indexOf(r.Stressors, name)
+}
+
+func (r *Resources) cellLevels(t CellType) (nutrients, stressors []float64) {
+	nutrients = make([]float64, len(r.Nutrients))
+	nutrients[0] = t.FoodStorage
+	for i := 1; i < len(r.Nutrients); i++ {
+		nutrients[i] = t.Nutrients[r.Nutrients[i]]
+	}
+	stressors = make([]float64, len(r.Stressors))
+	stressors[0] = t.Antibiotic
+	for i := 1; i < len(r.Stressors); i++ {
+		stressors[i] = t.Stressors[r.Stressors[i]]
+	}
+	return nutrients, stressors
+}
+
+// entityTraits returns values of the type traits in order of genes
+func (r *Resources) entityTraits(t EntityType) []float64 {
+	values := []float64{t.Resistance, t.GrownRateBase, t.ConsumptionBase}
+	for _, name := range r.Nutrients[1:] {
+		values = append(values, t.Needs[name])
+	}
+	for _, name := range r.Stressors[1:] {
+		values = append(values, t.Resistances[name])
+	}
+	// additional genes are zero unless the type has them
+	return append(values, make([]float64, len(r.Genes))...)
+}
+
+// stressorRatio is a level of the stressor relative to its range on the field, from 0 to 1
+func (r *Resources) stressorRatio(i int, level float64) float64 {
+	if i >= len(r.MaxStressors) || r.MaxStressors[i] <= r.MinStressors[i] {
+		return 0
+	}
+	return (level - r.MinStressors[i]) / (r.MaxStressors[i] - r.MinStressors[i])
+}
+
+// geneNames are names of entity genes, every gene defines the trait of the same name
+func (r *Resources) geneNames() []string {
+	names := []string{"resistance", "grownRateBase", "consumptionBase"}
+	for _, name := range r.Nutrients[1:] {
+		names = append(names, "consumption."+name)
+	}
+	for _, name := range r.Stressors[1:] {
+		names = append(names, "resistance."+name)
+	}
+	return append(names, r.Genes...)
+}
+
+// traitNames are names of entity traits in order of Entity.traitValues
+func (r *Resources) traitNames() []string {
+	return append(r.geneNames(), MutationChanceTrait)
+}
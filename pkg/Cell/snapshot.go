@@ -0,0 +1,203 @@
+package Cell
+
+import (
+	"cellMachine/pkg/utils"
+	"errors"
+)
+
+// snapshot is a serializable copy of the whole field state
+
+// per-substance values are indexed as FieldSnapshot.Resources
+
+type EntitySnapshot struct {
+	// alleles of every gene, genes of nutrients and stressors go first and Resources.Genes last
+	Genome         [][]float64
+	Genes          []Gene `json:",omitempty"`
+	MutationChance float64
+	Size           utils.Size
+	Lineage        Lineage
+	Motility       *Motility  `json:",omitempty"`
+	Mutation       *Mutation  `json:",omitempty"`
+	TradeOffs      []TradeOff `json:",omitempty"`
+	Age            uint64
+	// ageing rules of the type
+	MaxAge           uint64    `json:",omitempty"`
+	DeathProbability float64   `json:",omitempty"`
+	Division         *Division `json:",omitempty"`
+}
+
+type CellSnapshot struct {
+	Nutrients    []float64
+	MaxNutrients []float64
+	Stressors    []float64
+	Regeneration []Regeneration  `json:",omitempty"`
+	Entity       *EntitySnapshot `json:",omitempty"`
+}
+
+type FieldSnapshot struct {
+	W, H          int
+	Seed          int64
+	RandomState   uint64
+	FoodDrop      *FoodDrop `json:",omitempty"`
+	FoodDropCount uint32
+	Turn          uint64
+	NextID        uint64
+	Resources     Resources
+	Diffusion     Diffusion
+	Topology      Topology
+	// mutations of every trait, indexed as traits
+	Mutations []uint64
+	// indexed as [x][y]
+	Cells [][]CellSnapshot
+	// records of every entity if lineage tracking is enabled
+	Lineage []LineageRecord `json:",omitempty"`
+}
+
+// Snapshot shares level and genome slices with the field, so it should be serialized before the next turn
+func (field *CellField) Snapshot() FieldSnapshot {
+	snapshot := FieldSnapshot{
+		W:             field.W,
+		H:             field.H,
+		Seed:          field.seed,
+		RandomState:   field.source.state,
+		FoodDrop:      field.foodDrop,
+		FoodDropCount: field.foodDropCount,
+		Turn:          field.turn,
+		NextID:        field.nextID,
+		Resources:     field.resources,
+		Diffusion:     field.diffusion,
+		Topology:      field.topology,
+		Mutations:     field.mutations,
+	}
+	if field.lineage != nil {
+		snapshot.Lineage = field.lineage.records
+	}
+	snapshot.Cells = make([][]CellSnapshot, field.W)
+	for i := 0; i < field.W; i++ {
+		snapshot.Cells[i] = make([]CellSnapshot, field.H)
+		for j := 0; j < field.H; j++ {
+			c := &field.cells[i][j]
+			snapshot.Cells[i][j] = CellSnapshot{
+				Nutrients:    c.nutrients,
+				MaxNutrients: c.maxNutrients,
+				Stressors:    c.stressors,
+			}
+			for _, r := range c.regeneration {
+				snapshot.Cells[i][j].Regeneration = append(snapshot.Cells[i][j].Regeneration, r.Regeneration)
+			}
+			if c.entity != nil {
+				snapshot.Cells[i][j].Entity = &EntitySnapshot{
+					Genome:           c.entity.genome.alleles,
+					MutationChance:   c.entity.mutator.mutationChance,
+					Size:             c.entity.size,
+					Lineage:          c.entity.lineage,
+					Division:         c.entity.division,
+					Age:              c.entity.age,
+					MaxAge:           c.entity.maxAge,
+					DeathProbability: c.entity.deathProbability,
+				}
+				for _, t := range c.entity.tradeOffs {
+					snapshot.Cells[i][j].Entity.TradeOffs = append(snapshot.Cells[i][j].Entity.TradeOffs, t.TradeOff)
+				}
+				if c.entity.genome.rules != nil {
+					snapshot.Cells[i][j].Entity.Genes = c.entity.genome.rules.genes
+				}
+				if c.entity.mutator.model != nil {
+					snapshot.Cells[i][j].Entity.Mutation = c.entity.mutator.model.Mutation
+				}
+				if c.entity.motility != nil {
+					snapshot.Cells[i][j].Entity.Motility = &c.entity.motility.Motility
+				}
+			}
+		}
+	}
+	return snapshot
+}
+
+func NewFieldFromSnapshot(snapshot FieldSnapshot) (*CellField, error) {
+	if snapshot.W <= 0 || snapshot.H <= 0 || len(snapshot.Cells) != snapshot.W {
+		return nil, errors.New("invalid field size in snapshot")
+	}
+	resources := snapshot.Resources
+	nutrients, stressors, genes := len(resources.Nutrients), len(resources.Stressors), len(resources.geneNames())
+	if nutrients == 0 || stressors == 0 || len(resources.MinStressors) != stressors || len(resources.MaxStressors) != stressors {
+		return nil, errors.New("invalid resources in snapshot")
+	}
+
+	field := NewFieldWithResources(snapshot.W, snapshot.H, BaseCellType(), resources)
+	field.seed = snapshot.Seed
+	field.source.state = snapshot.RandomState
+	field.foodDrop = snapshot.FoodDrop
+	field.foodDropCount = snapshot.FoodDropCount
+	field.turn = snapshot.Turn
+	field.nextID = snapshot.NextID
+	if len(snapshot.Mutations) != len(field.mutations) {
+		return nil, errors.New("invalid mutation counters in snapshot")
+	}
+	copy(field.mutations, snapshot.Mutations)
+	err := field.SetTopology(snapshot.Topology)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Diffusion.enabled() {
+		if len(snapshot.Diffusion.Nutrients) != nutrients || len(snapshot.Diffusion.Stressors) != stressors {
+			return nil, errors.New("invalid diffusion in snapshot")
+		}
+		field.diffusion = snapshot.Diffusion
+	}
+	for i := 0; i < field.W; i++ {
+		if len(snapshot.Cells[i]) != field.H {
+			return nil, errors.New("invalid field size in snapshot")
+		}
+		for j := 0; j < field.H; j++ {
+			s := &snapshot.Cells[i][j]
+			c := &field.cells[i][j]
+			if len(s.Nutrients) != nutrients || len(s.MaxNutrients) != nutrients || len(s.Stressors) != stressors {
+				return nil, errors.New("invalid cell resources in snapshot")
+			}
+			copy(c.nutrients, s.Nutrients)
+			copy(c.maxNutrients, s.MaxNutrients)
+			copy(c.stressors, s.Stressors)
+			regeneration, err := field.resolveRegeneration(s.Regeneration)
+			if err != nil {
+				return nil, err
+			}
+			c.regeneration = regeneration
+			if s.Entity != nil {
+				if len(s.Entity.Genome) != genes {
+					return nil, errors.New("invalid entity genome in snapshot")
+				}
+				for _, alleles := range s.Entity.Genome {
+					if len(alleles) == 0 {
+						return nil, errors.New("invalid entity genome in snapshot")
+					}
+				}
+				e := new(Entity)
+				e.mutator = field.makeMutator(s.Entity.MutationChance, s.Entity.Mutation)
+				e.size = s.Entity.Size
+				e.lineage = s.Entity.Lineage
+				e.motility = resources.resolveMotility(s.Entity.Motility)
+				e.division = s.Entity.Division
+				e.age = s.Entity.Age
+				e.maxAge = s.Entity.MaxAge
+				e.deathProbability = s.Entity.DeathProbability
+				e.tradeOffs = resources.resolveTradeOffs(s.Entity.TradeOffs)
+				e.genome = genome{alleles: s.Entity.Genome, rules: resources.resolveGenes(s.Entity.Genes)}
+				e.express(nutrients, stressors)
+				e.calculateColor()
+				e.SetParent(c)
+				c.entity = e
+				field.entityCount++
+			}
+			c.updateColor()
+		}
+	}
+	if snapshot.Lineage != nil {
+		field.lineage = newLineageLog()
+		for _, record := range snapshot.Lineage {
+			field.lineage.index[record.ID] = len(field.lineage.records)
+			field.lineage.records = append(field.lineage.records, record)
+		}
+	}
+	return field, nil
+}
@@ -0,0 +1,92 @@
+package Cell
+
+import "math"
+
+type DeathCause int
+
+const (
+	DeathStarvation DeathCause = iota
+	DeathAntibiotic
+	// there was no free space for offspring during division
+	DeathCrowding
+	// killed by an intervention between turns
+	DeathWiped
+	// offspring or a moving entity crossed an absorbing edge
+	DeathAbsorbed
+	// killed by the death cost of a trait
+	DeathTradeOff
+	// lived longer than the max age of its type
+	DeathOldAge
+	// killed by the baseline death probability of its type
+	DeathRandom
+	DeathCauseCount
+)
+
+var deathCauseNames = [DeathCauseCount]string{"starvation", "antibiotic", "crowding", "wiped", "absorbed", "trade-off", "old-age", "random"}
+
+func (cause DeathCause) String() string {
+	return deathCauseNames[cause]
+}
+
+// TraitStats is a distribution of a trait across living entities
+type TraitStats struct {
+	Mean     float64
+	Variance float64
+	// mutations of the trait since the start
+	Mutations uint64
+}
+
+// FieldStats is a state of the field after the last turn. The population changes
+// by Births - Divisions - all Deaths since the previous turn.
+type FieldStats struct {
+	Population uint64
+	// daughters (including ones absorbed at edges) and entities put between turns
+	Births uint64
+	// entities replaced by their daughters
+	Divisions uint64
+	// movements of entities to neighbour cells
+	Moves      uint64
+	Deaths     [DeathCauseCount]uint64
+	TotalFood  float64
+	TraitNames []string
+	Traits     []TraitStats
+	// food drops and other events of the turn
+	Events []Event
+}
+
+// Stats collects the current population state together with counters of the last turn
+func (field *CellField) Stats() FieldStats {
+	stats := field.stats
+	stats.Population = field.entityCount
+	stats.TraitNames = field.resources.traitNames()
+
+	sums := make([]float64, len(stats.TraitNames))
+	squares := make([]float64, len(stats.TraitNames))
+	for i := 0; i < field.W; i++ {
+		for j := 0; j < field.H; j++ {
+			c := &field.cells[i][j]
+			stats.TotalFood += c.nutrients[0]
+			if c.entity != nil {
+				for k, value := range c.entity.traitValues() {
+					sums[k] += value
+					squares[k] += value * value
+				}
+			}
+		}
+	}
+
+	stats.Traits = make([]TraitStats, len(stats.TraitNames))
+	for k := range stats.Traits {
+		stats.Traits[k].Mutations = field.mutations[k]
+	}
+	if stats.Population > 0 {
+		n := float64(stats.Population)
+		for k := range stats.Traits {
+			mean := sums[k] / n
+			stats.Traits[k].Mean = mean
+			// avoid tiny negative values caused by rounding
+			stats.Traits[k].Variance = math.Max(squares[k]/n-mean*mean, 0)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,203 @@
+package Cell
+
+import (
+	"cellMachine/pkg/utils"
+	"errors"
+)
+
+// neighbourhoods of a cell
+const (
+	NeighbourhoodMoore      = "moore"
+	NeighbourhoodVonNeumann = "von-neumann"
+	// hexagonal grid in odd-r layout: odd rows are shifted right by a half of a cell
+	NeighbourhoodHex = "hex"
+)
+
+// behaviour of field edges
+const (
+	EdgesTorus = "torus"
+	// nothing crosses edges
+	EdgesWall = "wall"
+	// everything which crosses edges is lost
+	EdgesAbsorbing = "absorbing"
+)
+
+var (
+	Neighbourhoods = []string{NeighbourhoodMoore, NeighbourhoodVonNeumann, NeighbourhoodHex}
+	EdgeModes      = []string{EdgesTorus, EdgesWall, EdgesAbsorbing}
+)
+
+// Topology describes neighbours of cells, empty values mean a Moore neighbourhood
+// with radius 1 on a torus
+type Topology struct {
+	Neighbourhood string
+	Radius        int
+	Edges         string
+}
+
+func (t Topology) withDefaults() Topology {
+	if t.Neighbourhood == "" {
+		t.Neighbourhood = NeighbourhoodMoore
+	}
+	if t.Radius == 0 {
+		t.Radius = 1
+	}
+	if t.Edges == "" {
+		t.Edges = EdgesTorus
+	}
+	return t
+}
+
+// offsets of neighbours, hex grid has different offsets for even and odd rows
+type neighbourOffsets struct {
+	even, odd []utils.Position
+	// neighbours of distance 1 for diffusion
+	nearEven, nearOdd []utils.Position
+	// on a small torus different offsets could lead to the same cell or to the cell itself
+	overlap bool
+}
+
+func squareOffsets(radius int, vonNeumann bool) []utils.Position {
+	offsets := make([]utils.Position, 0)
+	for dx := -radius; dx <= radius; dx++ {
+		for dy := -radius; dy <= radius; dy++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			if vonNeumann && abs(dx)+abs(dy) > radius {
+				continue
+			}
+			offsets = append(offsets, utils.Position{X: dx, Y: dy})
+		}
+	}
+	return offsets
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// hexOffsets converts cube coordinates within the radius to odd-r offsets for the row parity
+func hexOffsets(radius int, parity int) []utils.Position {
+	offsets := make([]utils.Position, 0)
+	// cube coordinates of the origin cell in its own offset system
+	for q := -radius; q <= radius; q++ {
+		for r := -radius; r <= radius; r++ {
+			s := -q - r
+			if (q == 0 && r == 0) || abs(s) > radius {
+				continue
+			}
+			// odd-r: col = q + (r - (r & 1)) / 2, taking the parity of the origin row into account
+			row := parity + r
+			col := q + (row-(row&1))/2 - (parity-(parity&1))/2
+			offsets = append(offsets, utils.Position{X: col, Y: r})
+		}
+	}
+	return offsets
+}
+
+func newNeighbourOffsets(t Topology) neighbourOffsets {
+	var n neighbourOffsets
+	switch t.Neighbourhood {
+	case NeighbourhoodHex:
+		n.even, n.odd = hexOffsets(t.Radius, 0), hexOffsets(t.Radius, 1)
+		n.nearEven, n.nearOdd = hexOffsets(1, 0), hexOffsets(1, 1)
+	default:
+		n.even = squareOffsets(t.Radius, t.Neighbourhood == NeighbourhoodVonNeumann)
+		n.odd = n.even
+		n.nearEven = squareOffsets(1, true)
+		n.nearOdd = n.nearEven
+	}
+	return n
+}
+
+// SetTopology changes neighbours of cells and behaviour of field edges
+func (field *CellField) SetTopology(t Topology) error {
+	t = t.withDefaults()
+	known := false
+	for _, n := range Neighbourhoods {
+		known = known || n == t.Neighbourhood
+	}
+	if !known {
+		return errors.New("unknown neighbourhood " + t.Neighbourhood)
+	}
+	known = false
+	for _, e := range EdgeModes {
+		known = known || e == t.Edges
+	}
+	if !known {
+		return errors.New("unknown edges " + t.Edges)
+	}
+	if t.Radius < 0 {
+		return errors.New("negative neighbourhood radius")
+	}
+	field.topology = t
+	field.offsets = newNeighbourOffsets(t)
+	if t.Edges == EdgesTorus {
+		for _, offset := range append(field.offsets.even, field.offsets.odd...) {
+			if 2*abs(offset.X)+1 > field.W || 2*abs(offset.Y)+1 > field.H {
+				field.offsets.overlap = true
+			}
+		}
+	}
+	return nil
+}
+
+func (field *CellField) Topology() Topology {
+	return field.topology
+}
+
+func (field *CellField) contains(x, y int) bool {
+	return x >= 0 && x < field.W && y >= 0 && y < field.H
+}
+
+// wrap moves a position into the field on a torus, positions out of bounded fields are left as is
+func (field *CellField) wrap(x, y int) (int, int) {
+	if field.topology.Edges == EdgesTorus {
+		return (x%field.W + field.W) % field.W, (y%field.H + field.H) % field.H
+	}
+	return x, y
+}
+
+func (field *CellField) neighboursBy(x, y int, even, odd []utils.Position) []utils.Position {
+	offsets := even
+	if y&1 == 1 {
+		offsets = odd
+	}
+	positions := make([]utils.Position, 0, len(offsets))
+	for _, offset := range offsets {
+		posX, posY := field.wrap(x+offset.X, y+offset.Y)
+		if !field.contains(posX, posY) && field.topology.Edges != EdgesAbsorbing {
+			continue
+		}
+		pos := utils.Position{X: posX, Y: posY}
+		if field.offsets.overlap && (posX == x && posY == y || containsPosition(positions, pos)) {
+			continue
+		}
+		positions = append(positions, pos)
+	}
+	return positions
+}
+
+func containsPosition(positions []utils.Position, pos utils.Position) bool {
+	for _, p := range positions {
+		if p == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// neighbours returns positions of neighbour cells, with absorbing edges
+// they include positions out of the field where everything is lost
+func (field *CellField) neighbours(x, y int) []utils.Position {
+	return field.neighboursBy(x, y, field.offsets.even, field.offsets.odd)
+}
+
+// nearNeighbours returns the closest neighbours which exchange substances during diffusion
+func (field *CellField) nearNeighbours(x, y int) []utils.Position {
+	return field.neighboursBy(x, y, field.offsets.nearEven, field.offsets.nearOdd)
+}
@@ -0,0 +1,125 @@
+package Cell
+
+import (
+	"cellMachine/pkg/utils"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortPositions(positions []utils.Position) []utils.Position {
+	sorted := append([]utils.Position(nil), positions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Y != sorted[j].Y {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+	return sorted
+}
+
+func TestHexNeighbours(t *testing.T) {
+	tests := []struct {
+		name       string
+		edges      string
+		x, y       int
+		neighbours []utils.Position
+	}{
+		{
+			"even row inside", EdgesTorus, 1, 2,
+			[]utils.Position{{X: 0, Y: 1}, {X: 1, Y: 1}, {X: 0, Y: 2}, {X: 2, Y: 2}, {X: 0, Y: 3}, {X: 1, Y: 3}},
+		},
+		{
+			"odd row inside", EdgesTorus, 1, 1,
+			[]utils.Position{{X: 1, Y: 0}, {X: 2, Y: 0}, {X: 0, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}, {X: 2, Y: 2}},
+		},
+		{
+			"even row corner on torus", EdgesTorus, 0, 0,
+			[]utils.Position{{X: 1, Y: 0}, {X: 3, Y: 0}, {X: 0, Y: 1}, {X: 3, Y: 1}, {X: 0, Y: 3}, {X: 3, Y: 3}},
+		},
+		{
+			"odd row edge on torus", EdgesTorus, 3, 1,
+			[]utils.Position{{X: 0, Y: 0}, {X: 3, Y: 0}, {X: 0, Y: 1}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 3, Y: 2}},
+		},
+		{
+			"even row corner at wall", EdgesWall, 0, 0,
+			[]utils.Position{{X: 1, Y: 0}, {X: 0, Y: 1}},
+		},
+		{
+			"odd row edge at wall", EdgesWall, 3, 1,
+			[]utils.Position{{X: 3, Y: 0}, {X: 2, Y: 1}, {X: 3, Y: 2}},
+		},
+		{
+			"even row corner at absorbing edges", EdgesAbsorbing, 0, 0,
+			[]utils.Position{{X: -1, Y: -1}, {X: 0, Y: -1}, {X: -1, Y: 0}, {X: 1, Y: 0}, {X: -1, Y: 1}, {X: 0, Y: 1}},
+		},
+		{
+			"odd row edge at absorbing edges", EdgesAbsorbing, 3, 1,
+			[]utils.Position{{X: 3, Y: 0}, {X: 4, Y: 0}, {X: 2, Y: 1}, {X: 4, Y: 1}, {X: 3, Y: 2}, {X: 4, Y: 2}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := NewField(4, 4)
+			err := field.SetTopology(Topology{Neighbourhood: NeighbourhoodHex, Edges: test.edges})
+			if err != nil {
+				t.Fatal(err)
+			}
+			neighbours := sortPositions(field.neighbours(test.x, test.y))
+			expected := sortPositions(test.neighbours)
+			if !reflect.DeepEqual(neighbours, expected) {
+				t.Errorf("got %v, expected %v", neighbours, expected)
+			}
+		})
+	}
+}
+
+func TestHexOffsetsRadius(t *testing.T) {
+	for radius := 1; radius <= 3; radius++ {
+		for parity := 0; parity <= 1; parity++ {
+			// rings of 6, 12, 18... cells
+			if n := len(hexOffsets(radius, parity)); n != 3*radius*(radius+1) {
+				t.Errorf("radius %d, parity %d: got %d neighbours, expected %d", radius, parity, n, 3*radius*(radius+1))
+			}
+		}
+	}
+}
+
+func TestSmallTorusNeighbours(t *testing.T) {
+	tests := []struct {
+		name       string
+		topology   Topology
+		w, h, x, y int
+		neighbours []utils.Position
+	}{
+		{
+			"radius beyond the field", Topology{Radius: 3}, 3, 3, 1, 1,
+			[]utils.Position{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 0, Y: 1}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}},
+		},
+		{
+			"narrow field", Topology{Neighbourhood: NeighbourhoodVonNeumann, Radius: 2}, 1, 5, 0, 1,
+			[]utils.Position{{X: 0, Y: 0}, {X: 0, Y: 2}, {X: 0, Y: 3}, {X: 0, Y: 4}},
+		},
+		{
+			"hex", Topology{Neighbourhood: NeighbourhoodHex}, 2, 2, 1, 1,
+			[]utils.Position{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := NewField(test.w, test.h)
+			err := field.SetTopology(test.topology)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the cell itself is never its neighbour, every neighbour is listed once
+			neighbours := sortPositions(field.neighbours(test.x, test.y))
+			expected := sortPositions(test.neighbours)
+			if !reflect.DeepEqual(neighbours, expected) {
+				t.Errorf("got %v, expected %v", neighbours, expected)
+			}
+		})
+	}
+}
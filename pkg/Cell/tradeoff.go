@@ -0,0 +1,110 @@
+package Cell
+
+import "math"
+
+// what a trait costs
+const (
+	// extra consumption of a nutrient every turn
+	CostConsumption = "consumption"
+	// probability to die every turn
+	CostDeath = "death"
+	// share of the growth rate which is lost
+	CostGrowth = "growth"
+)
+
+// shapes of the cost of a trait value x above the threshold
+const (
+	// Weight * x
+	CostLinear = "linear"
+	// Weight * x^2
+	CostQuadratic = "quadratic"
+	// Weight * (e^x - 1)
+	CostExponential = "exponential"
+)
+
+var (
+	CostKinds     = []string{CostConsumption, CostDeath, CostGrowth}
+	CostFunctions = []string{CostLinear, CostQuadratic, CostExponential}
+)
+
+// TradeOff makes entities pay for a value of the trait
+type TradeOff struct {
+	// name of the trait as in metrics, e.g. "resistance"
+	Trait string
+	Cost  string
+	// linear by default
+	Function string
+	Weight   float64
+	// only the part of the value above the threshold costs
+	Threshold float64
+	// nutrient of the consumption cost, food by default
+	Substance string `json:",omitempty"`
+}
+
+func (t *TradeOff) cost(value float64) float64 {
+	x := math.Max(value-t.Threshold, 0)
+	switch t.Function {
+	case CostQuadratic:
+		return t.Weight * x * x
+	case CostExponential:
+		return t.Weight * (math.Exp(x) - 1)
+	default:
+		return t.Weight * x
+	}
+}
+
+// trade-off with the trait and the nutrient resolved by field resources
+type tradeOff struct {
+	TradeOff
+	trait    int
+	nutrient int
+}
+
+func (r *Resources) resolveTradeOffs(tradeOffs []TradeOff) []tradeOff {
+	names := r.traitNames()
+	resolved := make([]tradeOff, 0, len(tradeOffs))
+	for _, t := range tradeOffs {
+		trait := indexOf(names, t.Trait)
+		if trait < 0 {
+			continue
+		}
+		nutrient := 0
+		if t.Substance != "" {
+			nutrient = r.NutrientIndex(t.Substance)
+		}
+		if t.Cost == CostConsumption && nutrient < 0 {
+			continue
+		}
+		resolved = append(resolved, tradeOff{TradeOff: t, trait: trait, nutrient: nutrient})
+	}
+	return resolved
+}
+
+// costs of traits of an entity, they do not change during its life
+type entityCosts struct {
+	// needs with extra consumption
+	demands     []float64
+	deathChance float64
+	// share of the growth rate which is left
+	growthFactor float64
+}
+
+func (e *Entity) calculateCosts() {
+	e.costs = entityCosts{demands: e.needs, growthFactor: 1}
+	if len(e.tradeOffs) == 0 {
+		return
+	}
+	values := e.traitValues()
+	e.costs.demands = append([]float64(nil), e.needs...)
+	for _, t := range e.tradeOffs {
+		cost := t.cost(values[t.trait])
+		switch t.Cost {
+		case CostConsumption:
+			e.costs.demands[t.nutrient] += cost
+		case CostDeath:
+			e.costs.deathChance += cost
+		case CostGrowth:
+			e.costs.growthFactor *= math.Max(1-cost, 0)
+		}
+	}
+}
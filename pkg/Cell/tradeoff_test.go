@@ -0,0 +1,85 @@
+package Cell
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTradeOffCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		tradeOff TradeOff
+		value    float64
+		cost     float64
+	}{
+		{"linear by default", TradeOff{Weight: 0.5}, 4, 2},
+		{"linear above threshold", TradeOff{Function: CostLinear, Weight: 0.5, Threshold: 3}, 4, 0.5},
+		{"below threshold", TradeOff{Function: CostQuadratic, Weight: 0.5, Threshold: 5}, 4, 0},
+		{"quadratic", TradeOff{Function: CostQuadratic, Weight: 0.5, Threshold: 1}, 4, 4.5},
+		{"exponential", TradeOff{Function: CostExponential, Weight: 2}, 1, 2 * (math.E - 1)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if cost := test.tradeOff.cost(test.value); math.Abs(cost-test.cost) > 1e-9 {
+				t.Errorf("got cost %g, expected %g", cost, test.cost)
+			}
+		})
+	}
+}
+
+func TestEntityCosts(t *testing.T) {
+	cellType := CellType{Name: "plain", FoodStorage: 100, Nutrients: map[string]float64{"iron": 10}}
+	entityType := EntityType{
+		Name: "bug", Resistance: 6, GrownRateBase: 0.5, ConsumptionBase: 1,
+		Needs: map[string]float64{"iron": 0.2},
+		TradeOffs: []TradeOff{
+			{Trait: "resistance", Cost: CostConsumption, Weight: 0.1, Threshold: 2},
+			{Trait: "resistance", Cost: CostConsumption, Weight: 0.05, Substance: "iron"},
+			{Trait: "grownRateBase", Cost: CostDeath, Function: CostQuadratic, Weight: 0.4},
+			{Trait: "resistance", Cost: CostGrowth, Weight: 0.05},
+			{Trait: "resistance", Cost: CostGrowth, Weight: 1, Threshold: 5},
+			// unknown traits and nutrients are ignored
+			{Trait: "speed", Cost: CostDeath, Weight: 1},
+			{Trait: "resistance", Cost: CostConsumption, Weight: 1, Substance: "zinc"},
+		},
+	}
+	resources := NewResources([]CellType{cellType}, []EntityType{entityType})
+	e := NewEntityFromEntityType(entityType, &resources, Mutator{})
+	iron := resources.NutrientIndex("iron")
+
+	if demand := e.costs.demands[0]; math.Abs(demand-1.4) > 1e-9 {
+		t.Errorf("got food demand %g, expected 1.4", demand)
+	}
+	if demand := e.costs.demands[iron]; math.Abs(demand-0.5) > 1e-9 {
+		t.Errorf("got iron demand %g, expected 0.5", demand)
+	}
+	// needs stay the same, costs are added to them
+	if e.needs[0] != 1 || e.needs[iron] != 0.2 {
+		t.Errorf("got needs %v, expected [1 0.2]", e.needs)
+	}
+	if math.Abs(e.costs.deathChance-0.1) > 1e-9 {
+		t.Errorf("got death chance %g, expected 0.1", e.costs.deathChance)
+	}
+	// the first growth cost leaves 0.7 of the rate, the second one takes all the rest
+	if e.costs.growthFactor != 0 {
+		t.Errorf("got growth factor %g, expected 0", e.costs.growthFactor)
+	}
+}
+
+func TestTradeOffDeath(t *testing.T) {
+	field := NewField(3, 3)
+	field.SetSeed(1)
+	err := field.DropEntityRect(1, 1, 1, 1, EntityType{
+		Name: "bug", Resistance: 10, ConsumptionBase: 1,
+		TradeOffs: []TradeOff{{Trait: "resistance", Cost: CostDeath, Weight: 1, Threshold: 9}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field.FinishSeeding()
+	field.Update()
+	if deaths := field.Stats().Deaths[DeathTradeOff]; deaths != 1 || field.EntityCount() != 0 {
+		t.Errorf("got %d trade-off deaths and %d entities, expected the entity to die", deaths, field.EntityCount())
+	}
+}
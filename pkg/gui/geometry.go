@@ -0,0 +1,78 @@
+package gui
+
+import (
+	"cellMachine/pkg/utils"
+	"github.com/andlabs/ui"
+	"math"
+)
+
+// geometry is a placement of cells in the area. Hex cells are pointy-topped,
+// odd rows are shifted right by a half of a cell and rows overlap by a third of the height.
+type geometry struct {
+	w, h float64
+	hex  bool
+}
+
+func newGeometry(composer utils.FieldComposer, areaWidth, areaHeight float64) geometry {
+	if composer.Hex {
+		return geometry{
+			w:   areaWidth / (float64(composer.W) + 0.5),
+			h:   areaHeight / (float64(composer.H) + 1.0/3),
+			hex: true,
+		}
+	}
+	return geometry{w: areaWidth / float64(composer.W), h: areaHeight / float64(composer.H)}
+}
+
+func (g geometry) center(i, j int) Point {
+	if !g.hex {
+		return Point{g.w * (float64(i) + 0.5), g.h * (float64(j) + 0.5)}
+	}
+	x := g.w * (float64(i) + 0.5)
+	if j&1 == 1 {
+		x += g.w / 2
+	}
+	return Point{x, g.h * (float64(j) + 2.0/3)}
+}
+
+// path returns an outline of the cell
+func (g geometry) path(i, j int) *ui.DrawPath {
+	if !g.hex {
+		return drawRect(Point{g.w * float64(i), g.h * float64(j)}, g.w, g.h)
+	}
+	c := g.center(i, j)
+	path := ui.DrawNewPath(ui.DrawFillModeWinding)
+	path.NewFigure(c.x, c.y-2*g.h/3)
+	path.LineTo(c.x+g.w/2, c.y-g.h/3)
+	path.LineTo(c.x+g.w/2, c.y+g.h/3)
+	path.LineTo(c.x, c.y+2*g.h/3)
+	path.LineTo(c.x-g.w/2, c.y+g.h/3)
+	path.LineTo(c.x-g.w/2, c.y-g.h/3)
+	path.CloseFigure()
+	path.End()
+	return path
+}
+
+// radius of an entity circle of the size
+func (g geometry) radius(size utils.Size) float64 {
+	return float64(size) * math.Min(g.w, g.h) * 0.5
+}
+
+// cellAt returns the cell under the point, hex cell is the one with the nearest center
+func (g geometry) cellAt(x, y float64) (int, int) {
+	i, j := int(math.Floor(x/g.w)), int(math.Floor(y/g.h))
+	if !g.hex {
+		return i, j
+	}
+	bestI, bestJ, bestDist := i, j, math.Inf(1)
+	for dj := -1; dj <= 1; dj++ {
+		for di := -1; di <= 1; di++ {
+			c := g.center(i+di, j+dj)
+			dx, dy := (x-c.x)/g.w, (y-c.y)/g.h
+			if dist := dx*dx + dy*dy; dist < bestDist {
+				bestI, bestJ, bestDist = i+di, j+dj, dist
+			}
+		}
+	}
+	return bestI, bestJ
+}
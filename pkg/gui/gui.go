@@ -15,6 +15,12 @@ const (
 	strMutations = "Mutations: "
 	strEntities  = "Entities: "
 	strTurns     = "Turns: "
+	strPause     = "Pause"
+	strResume    = "Resume"
+	strStep      = "Step"
+	strRecord    = "Record GIF"
+	strStopRec   = "Stop recording"
+	recordEvery  = 10
 	fieldW       = 800
 	fieldH       = 800
 	infoH        = 100
@@ -25,6 +31,10 @@ var (
 	Warning     *log.Logger
 	Error       *log.Logger
 	redrawDelay = 100 * time.Millisecond
+
+	// turns per second, zero means unthrottled simulation
+	speeds       = []float64{1, 5, 20, 50, 200, 0}
+	defaultSpeed = 3
 )
 
 func initUILog() {
@@ -66,26 +76,52 @@ var (
 	}
 )
 
+// Controller is a part of the simulator which is controlled by user
+type Controller interface {
+	Pause()
+	Resume()
+	IsPaused() bool
+	Step(turns int)
+	SetSpeed(turnsPerSecond float64)
+	Inspect(x, y int) (utils.CellInfo, bool)
+	CellTypeNames() []string
+	EntityTypeNames() []string
+	Paint(brush utils.Brush) error
+	StartRecording(path string, every uint64) error
+	StopRecording() error
+	IsRecording() bool
+	Layers() []string
+	SetLayer(layer int)
+}
+
 type Uicore struct {
 	CloseApp     chan<- bool
 	ComposerChan <-chan utils.FieldComposer
 	ReadyChan    chan<- utils.Ready
+	Controller   Controller
 	redrawTimer  time.Ticker
 
 	composer utils.FieldComposer
+	speed    int
 
 	mainwin       *ui.Window
 	area          *ui.Area
 	turnLabel     *ui.Label
 	entityLabel   *ui.Label
 	mutationLabel *ui.Label
+	pauseButton   *ui.Button
+	recordButton  *ui.Button
+	speedBox      *ui.Combobox
+	layerBox      *ui.Combobox
+	inspector     *inspector
+	toolbar       *toolbar
 }
 
 func (core *Uicore) Init() {
 	initUILog()
 	Log.Println("UI initialization...")
 
-	core.mainwin = ui.NewWindow("cell machine", fieldW, fieldH+infoH, true)
+	core.mainwin = ui.NewWindow("cell machine", fieldW+inspectorW, fieldH+infoH, true)
 	core.mainwin.SetMargined(true)
 	core.mainwin.OnClosing(core.OnCloseWindow)
 
@@ -97,11 +133,60 @@ func (core *Uicore) Init() {
 	core.entityLabel = ui.NewLabel(strEntities)
 	infoBox.Append(core.entityLabel, true)
 
+	controlBox := ui.NewHorizontalBox()
+	controlBox.SetPadded(true)
+	core.pauseButton = ui.NewButton(strPause)
+	core.pauseButton.OnClicked(func(*ui.Button) {
+		core.togglePause()
+	})
+	controlBox.Append(core.pauseButton, false)
+	stepButton := ui.NewButton(strStep)
+	stepButton.OnClicked(func(*ui.Button) {
+		core.step()
+	})
+	controlBox.Append(stepButton, false)
+	core.speedBox = ui.NewCombobox()
+	for _, speed := range speeds {
+		if speed > 0 {
+			core.speedBox.Append(strconv.FormatFloat(speed, 'f', -1, 64) + " turns/s")
+		} else {
+			core.speedBox.Append("unlimited")
+		}
+	}
+	core.speedBox.OnSelected(func(box *ui.Combobox) {
+		core.setSpeed(box.Selected())
+	})
+	controlBox.Append(core.speedBox, false)
+	core.setSpeed(defaultSpeed)
+	core.recordButton = ui.NewButton(strRecord)
+	core.recordButton.OnClicked(func(*ui.Button) {
+		core.toggleRecording()
+	})
+	controlBox.Append(core.recordButton, false)
+	core.layerBox = ui.NewCombobox()
+	for _, layer := range core.Controller.Layers() {
+		core.layerBox.Append(layer)
+	}
+	core.layerBox.SetSelected(0)
+	core.layerBox.OnSelected(func(box *ui.Combobox) {
+		core.Controller.SetLayer(box.Selected())
+	})
+	controlBox.Append(core.layerBox, false)
+
 	areaHandler := areaHandler{composerChannel: core.ComposerChan, core: core}
 	core.area = ui.NewArea(&areaHandler)
 
+	core.inspector = newInspector()
+	fieldBox := ui.NewHorizontalBox()
+	fieldBox.SetPadded(true)
+	fieldBox.Append(core.area, true)
+	fieldBox.Append(core.inspector.group, false)
+
 	gameBox := ui.NewVerticalBox()
-	gameBox.Append(core.area, true)
+	gameBox.Append(fieldBox, true)
+	gameBox.Append(controlBox, false)
+	core.toolbar = newToolbar(core.Controller)
+	gameBox.Append(core.toolbar.box, false)
 	gameBox.Append(infoBox, false)
 	core.mainwin.SetChild(gameBox)
 
@@ -130,6 +215,63 @@ func (core *Uicore) OnCloseWindow(window *ui.Window) bool {
 	return true
 }
 
+func (core *Uicore) togglePause() {
+	if core.Controller.IsPaused() {
+		core.Controller.Resume()
+	} else {
+		core.Controller.Pause()
+	}
+	core.updateControls()
+}
+
+func (core *Uicore) step() {
+	core.Controller.Step(1)
+	core.updateControls()
+}
+
+func (core *Uicore) setSpeed(index int) {
+	if index < 0 || index >= len(speeds) {
+		return
+	}
+	core.speed = index
+	core.speedBox.SetSelected(index)
+	core.Controller.SetSpeed(speeds[index])
+}
+
+func (core *Uicore) toggleRecording() {
+	if core.Controller.IsRecording() {
+		// frames are already written, only the file is closed
+		err := core.Controller.StopRecording()
+		if err != nil {
+			Error.Printf("Cannot save recording: %s", err.Error())
+		}
+	} else {
+		path := ui.SaveFile(core.mainwin)
+		if path == "" {
+			return
+		}
+		err := core.Controller.StartRecording(path, recordEvery)
+		if err != nil {
+			ui.MsgBoxError(core.mainwin, "Recording", err.Error())
+		}
+	}
+	core.updateControls()
+}
+
+// simulation could be paused by itself, so controls are updated on every redraw
+func (core *Uicore) updateControls() {
+	if core.Controller.IsPaused() {
+		core.pauseButton.SetText(strResume)
+	} else {
+		core.pauseButton.SetText(strPause)
+	}
+	if core.Controller.IsRecording() {
+		core.recordButton.SetText(strStopRec)
+	} else {
+		core.recordButton.SetText(strRecord)
+	}
+}
+
 func drawLine(from, to Point) *ui.DrawPath {
 	path := ui.DrawNewPath(ui.DrawFillModeWinding)
 	path.NewFigure(from.x, from.y)
@@ -153,41 +295,41 @@ func drawCircle(center Point, radius float64) *ui.DrawPath {
 }
 
 func handleComposer(composer utils.FieldComposer, params *ui.AreaDrawParams) {
-	cellWidth := params.AreaWidth / float64(composer.W)
-	cellHeight := params.AreaHeight / float64(composer.H)
+	g := newGeometry(composer, params.AreaWidth, params.AreaHeight)
 
 	for i := range composer.Cells {
 		for j := range composer.Cells[i] {
 			cellComposer := &composer.Cells[i][j]
 			brush := NewBrush(cellComposer.BackColor)
-			cellPath := drawRect(Point{cellWidth * float64(i), cellHeight * float64(j)}, cellWidth, cellHeight)
+			cellPath := g.path(i, j)
 			params.Context.Fill(cellPath, &brush)
+			// hex cells have no common grid lines, so every one is outlined
+			if g.hex {
+				params.Context.Stroke(cellPath, &strokeBrush, &strokeParams)
+			}
 			cellPath.Free()
 
 			if cellComposer.Composer.Size > 0 {
-				center := Point{cellWidth * (float64(i) + 0.5), cellHeight * (float64(j) + 0.5)}
-				radius := float64(cellComposer.Composer.Size) * cellWidth * 0.5
-				if cellWidth > cellHeight {
-					radius = float64(cellComposer.Composer.Size) * cellHeight * 0.5
-				}
-
-				entityPath := drawCircle(center, radius)
+				entityPath := drawCircle(g.center(i, j), g.radius(cellComposer.Composer.Size))
 				brush = NewBrush(cellComposer.Composer.Color)
 				params.Context.Fill(entityPath, &brush)
 				entityPath.Free()
 			}
 		}
 	}
+	if g.hex {
+		return
+	}
 
 	// lines
 	for i := 1; i < composer.W; i++ {
-		x := cellWidth * float64(i)
+		x := g.w * float64(i)
 		path := drawLine(Point{x, 0}, Point{x, params.AreaHeight})
 		params.Context.Stroke(path, &strokeBrush, &strokeParams)
 		path.Free()
 	}
 	for i := 1; i < composer.H; i++ {
-		y := cellHeight * float64(i)
+		y := g.h * float64(i)
 		path := drawLine(Point{0, y}, Point{params.AreaWidth, y})
 		params.Context.Stroke(path, &strokeBrush, &strokeParams)
 		path.Free()
@@ -204,24 +346,72 @@ func (handler *areaHandler) Draw(a *ui.Area, p *ui.AreaDrawParams) {
 	handler.core.turnLabel.SetText(strTurns + strconv.FormatUint(handler.core.composer.Turns, 10))
 	handler.core.mutationLabel.SetText(strMutations + strconv.FormatUint(handler.core.composer.Mutations, 10))
 	handler.core.entityLabel.SetText(strEntities + strconv.FormatUint(handler.core.composer.Entities, 10))
+	handler.core.updateControls()
 	if handler.core.composer.Cells != nil {
 		handleComposer(handler.core.composer, p)
+		handler.core.inspector.draw(handler.core.composer, p)
 	}
+	handler.core.inspector.update(handler.core.Controller)
 }
 
-func (areaHandler) MouseEvent(a *ui.Area, me *ui.AreaMouseEvent) {
-	// do nothing
+// cellAt converts a mouse position to a cell index
+func cellAt(composer utils.FieldComposer, me *ui.AreaMouseEvent) (x, y int, ok bool) {
+	if composer.W == 0 || composer.H == 0 || me.AreaWidth <= 0 || me.AreaHeight <= 0 {
+		return 0, 0, false
+	}
+	x, y = newGeometry(composer, me.AreaWidth, me.AreaHeight).cellAt(me.X, me.Y)
+	if x < 0 || x >= composer.W || y < 0 || y >= composer.H {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+func (handler *areaHandler) MouseEvent(a *ui.Area, me *ui.AreaMouseEvent) {
+	x, y, ok := cellAt(handler.core.composer, me)
+	if !ok {
+		return
+	}
+	if handler.core.toolbar.isPainting() {
+		handler.core.toolbar.mouseEvent(handler.core.Controller, x, y, me)
+		return
+	}
+	if me.Down == 1 {
+		handler.core.inspector.selectCell(x, y)
+		handler.core.inspector.update(handler.core.Controller)
+		a.QueueRedrawAll()
+	}
 }
 
 func (areaHandler) MouseCrossed(a *ui.Area, left bool) {
 	// do nothing
 }
 
-func (areaHandler) DragBroken(a *ui.Area) {
-	// do nothing
+func (handler *areaHandler) DragBroken(a *ui.Area) {
+	handler.core.toolbar.dragging = false
 }
 
-func (areaHandler) KeyEvent(a *ui.Area, ke *ui.AreaKeyEvent) (handled bool) {
-	// reject all keys
-	return false
+func (handler *areaHandler) KeyEvent(a *ui.Area, ke *ui.AreaKeyEvent) (handled bool) {
+	core := handler.core
+	switch {
+	case ke.Key == ' ':
+		if !ke.Up {
+			core.togglePause()
+		}
+	case ke.Key == 'n' || ke.ExtKey == ui.Right:
+		if !ke.Up {
+			core.step()
+		}
+	case ke.Key == '+' || ke.Key == '=' || ke.ExtKey == ui.Up:
+		if !ke.Up {
+			core.setSpeed(core.speed + 1)
+		}
+	case ke.Key == '-' || ke.ExtKey == ui.Down:
+		if !ke.Up {
+			core.setSpeed(core.speed - 1)
+		}
+	default:
+		// reject other keys
+		return false
+	}
+	return true
 }
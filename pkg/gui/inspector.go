@@ -0,0 +1,156 @@
+package gui
+
+import (
+	"cellMachine/pkg/utils"
+	"fmt"
+	"github.com/andlabs/ui"
+	"strconv"
+)
+
+const (
+	inspectorW  = 250
+	strNone     = "-"
+	strNoEntity = "empty"
+)
+
+var selectionBrush = ui.DrawBrush{
+	R: 1.0,
+	G: 1.0,
+	B: 1.0,
+	A: 1.0,
+}
+
+// inspector shows details of the selected cell and updates them on every redraw
+type inspector struct {
+	group    *ui.Group
+	selected bool
+	x, y     int
+
+	cellLabel        *ui.Label
+	nutrientsLabel   *ui.Label
+	stressorsLabel   *ui.Label
+	entityLabel      *ui.Label
+	resistanceLabel  *ui.Label
+	growthLabel      *ui.Label
+	consumptionLabel *ui.Label
+	genesLabel       *ui.Label
+	mutationLabel    *ui.Label
+	sizeLabel        *ui.Label
+	motilityLabel    *ui.Label
+	lineageLabel     *ui.Label
+	typeLabel        *ui.Label
+	birthLabel       *ui.Label
+	ageLabel         *ui.Label
+}
+
+func newInspector() *inspector {
+	ins := new(inspector)
+	form := ui.NewForm()
+	form.SetPadded(true)
+	appendLabel := func(name string) *ui.Label {
+		label := ui.NewLabel(strNone)
+		form.Append(name, label, false)
+		return label
+	}
+	ins.cellLabel = appendLabel("Cell")
+	ins.nutrientsLabel = appendLabel("Nutrients")
+	ins.stressorsLabel = appendLabel("Stressors")
+	ins.entityLabel = appendLabel("Entity")
+	ins.resistanceLabel = appendLabel("Resistances")
+	ins.growthLabel = appendLabel("Growth rate")
+	ins.consumptionLabel = appendLabel("Needs")
+	ins.genesLabel = appendLabel("Genes")
+	ins.mutationLabel = appendLabel("Mutation chance")
+	ins.sizeLabel = appendLabel("Size")
+	ins.motilityLabel = appendLabel("Motility")
+	ins.lineageLabel = appendLabel("Lineage")
+	ins.typeLabel = appendLabel("Ancestor type")
+	ins.birthLabel = appendLabel("Birth turn")
+	ins.ageLabel = appendLabel("Age")
+
+	ins.group = ui.NewGroup("Inspector")
+	ins.group.SetMargined(true)
+	ins.group.SetChild(form)
+	return ins
+}
+
+func (ins *inspector) selectCell(x, y int) {
+	ins.selected = true
+	ins.x = x
+	ins.y = y
+}
+
+// formatLevels shows every level on its own line
+func formatLevels(levels []utils.Level, precision int, withMax bool) string {
+	text := ""
+	for i, level := range levels {
+		if i > 0 {
+			text += "\n"
+		}
+		text += level.Name + ": " + strconv.FormatFloat(level.Value, 'f', precision, 64)
+		if withMax {
+			text += " / " + strconv.FormatFloat(level.Max, 'f', precision, 64)
+		}
+	}
+	return text
+}
+
+func (ins *inspector) update(controller Controller) {
+	if !ins.selected {
+		return
+	}
+	info, ok := controller.Inspect(ins.x, ins.y)
+	if !ok {
+		ins.selected = false
+		return
+	}
+
+	ins.cellLabel.SetText(fmt.Sprintf("%d : %d", info.X, info.Y))
+	ins.nutrientsLabel.SetText(formatLevels(info.Nutrients, 1, true))
+	ins.stressorsLabel.SetText(formatLevels(info.Stressors, 2, false))
+
+	labels := []*ui.Label{ins.resistanceLabel, ins.growthLabel, ins.consumptionLabel, ins.genesLabel, ins.mutationLabel, ins.sizeLabel, ins.motilityLabel,
+		ins.lineageLabel, ins.typeLabel, ins.birthLabel, ins.ageLabel}
+	if info.Entity == nil {
+		ins.entityLabel.SetText(strNoEntity)
+		for _, label := range labels {
+			label.SetText(strNone)
+		}
+		return
+	}
+	e := info.Entity
+	ins.entityLabel.SetText("alive")
+	ins.resistanceLabel.SetText(formatLevels(e.Resistances, 3, false))
+	ins.growthLabel.SetText(fmt.Sprintf("%.3f", e.GrownRateBase))
+	ins.consumptionLabel.SetText(formatLevels(e.Needs, 3, false))
+	if len(e.Genes) == 0 {
+		ins.genesLabel.SetText(strNone)
+	} else {
+		ins.genesLabel.SetText(formatLevels(e.Genes, 3, false))
+	}
+	ins.mutationLabel.SetText(fmt.Sprintf("%.3f", e.MutationChance))
+	ins.sizeLabel.SetText(fmt.Sprintf("%.2f", e.Size))
+	if e.Motility == "" {
+		ins.motilityLabel.SetText("none")
+	} else {
+		ins.motilityLabel.SetText(e.Motility)
+	}
+	ins.lineageLabel.SetText(fmt.Sprintf("#%d of #%d, generation %d", e.ID, e.ParentID, e.Generation))
+	ins.typeLabel.SetText(e.TypeName)
+	ins.birthLabel.SetText(fmt.Sprintf("%d", e.BirthTurn))
+	if e.MaxAge > 0 {
+		ins.ageLabel.SetText(fmt.Sprintf("%d / %d", e.Age, e.MaxAge))
+	} else {
+		ins.ageLabel.SetText(fmt.Sprintf("%d", e.Age))
+	}
+}
+
+// draw a frame around the selected cell
+func (ins *inspector) draw(composer utils.FieldComposer, params *ui.AreaDrawParams) {
+	if !ins.selected || composer.W == 0 || composer.H == 0 {
+		return
+	}
+	path := newGeometry(composer, params.AreaWidth, params.AreaHeight).path(ins.x, ins.y)
+	params.Context.Stroke(path, &selectionBrush, &ui.DrawStrokeParams{Thickness: 2.0})
+	path.Free()
+}
@@ -0,0 +1,141 @@
+package gui
+
+import (
+	"cellMachine/pkg/utils"
+	"github.com/andlabs/ui"
+)
+
+const (
+	toolInspect = iota
+	toolPaint
+)
+
+const (
+	defaultBrushRadius = 2
+	maxBrushRadius     = 40
+)
+
+type paintType struct {
+	entity bool
+	name   string
+}
+
+// toolbar allows to paint configured cell and entity types on the field by mouse
+type toolbar struct {
+	box       *ui.Box
+	toolBox   *ui.Combobox
+	typeBox   *ui.Combobox
+	shapeBox  *ui.Combobox
+	radiusBox *ui.Spinbox
+	types     []paintType
+
+	// mouse drag state
+	dragging       bool
+	startX, startY int
+	lastX, lastY   int
+}
+
+func newToolbar(controller Controller) *toolbar {
+	bar := new(toolbar)
+	bar.box = ui.NewHorizontalBox()
+	bar.box.SetPadded(true)
+
+	bar.toolBox = ui.NewCombobox()
+	bar.toolBox.Append("Inspect")
+	bar.toolBox.Append("Paint")
+	bar.toolBox.SetSelected(toolInspect)
+	bar.box.Append(ui.NewLabel("Tool"), false)
+	bar.box.Append(bar.toolBox, false)
+
+	bar.typeBox = ui.NewCombobox()
+	for _, name := range controller.CellTypeNames() {
+		bar.types = append(bar.types, paintType{entity: false, name: name})
+		bar.typeBox.Append("cell: " + name)
+	}
+	for _, name := range controller.EntityTypeNames() {
+		bar.types = append(bar.types, paintType{entity: true, name: name})
+		bar.typeBox.Append("entity: " + name)
+	}
+	if len(bar.types) > 0 {
+		bar.typeBox.SetSelected(0)
+	}
+	bar.box.Append(ui.NewLabel("Type"), false)
+	bar.box.Append(bar.typeBox, false)
+
+	bar.shapeBox = ui.NewCombobox()
+	bar.shapeBox.Append("circle")
+	bar.shapeBox.Append("rectangle")
+	bar.shapeBox.SetSelected(int(utils.BrushCircle))
+	bar.box.Append(ui.NewLabel("Brush"), false)
+	bar.box.Append(bar.shapeBox, false)
+
+	bar.radiusBox = ui.NewSpinbox(0, maxBrushRadius)
+	bar.radiusBox.SetValue(defaultBrushRadius)
+	bar.box.Append(ui.NewLabel("Radius"), false)
+	bar.box.Append(bar.radiusBox, false)
+	return bar
+}
+
+func (bar *toolbar) isPainting() bool {
+	return bar.toolBox.Selected() == toolPaint
+}
+
+func (bar *toolbar) brush() (utils.Brush, bool) {
+	index := bar.typeBox.Selected()
+	if index < 0 || index >= len(bar.types) {
+		return utils.Brush{}, false
+	}
+	return utils.Brush{
+		Shape:    utils.BrushShape(bar.shapeBox.Selected()),
+		Entity:   bar.types[index].entity,
+		TypeName: bar.types[index].name,
+	}, true
+}
+
+func (bar *toolbar) paint(controller Controller, brush utils.Brush) {
+	err := controller.Paint(brush)
+	if err != nil {
+		Warning.Printf("Cannot paint: %s", err.Error())
+	}
+}
+
+// circles are painted along the mouse path, rectangles are painted from the press point to the release point
+func (bar *toolbar) mouseEvent(controller Controller, x, y int, me *ui.AreaMouseEvent) {
+	brush, ok := bar.brush()
+	if !ok {
+		return
+	}
+	brush.R = bar.radiusBox.Value()
+
+	switch {
+	case me.Down == 1:
+		bar.dragging = true
+		bar.startX, bar.startY = x, y
+		bar.lastX, bar.lastY = x, y
+		if brush.Shape == utils.BrushCircle {
+			brush.X, brush.Y = x, y
+			bar.paint(controller, brush)
+		}
+	case me.Up == 1 && bar.dragging:
+		bar.dragging = false
+		if brush.Shape == utils.BrushRect {
+			brush.X, brush.W = bar.startX, x-bar.startX
+			if x < bar.startX {
+				brush.X, brush.W = x, bar.startX-x
+			}
+			brush.Y, brush.H = bar.startY, y-bar.startY
+			if y < bar.startY {
+				brush.Y, brush.H = y, bar.startY-y
+			}
+			brush.W++
+			brush.H++
+			bar.paint(controller, brush)
+		}
+	case me.Held1To64&1 != 0 && bar.dragging:
+		if brush.Shape == utils.BrushCircle && (x != bar.lastX || y != bar.lastY) {
+			brush.X, brush.Y = x, y
+			bar.paint(controller, brush)
+		}
+		bar.lastX, bar.lastY = x, y
+	}
+}
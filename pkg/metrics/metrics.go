@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"bufio"
+	"cellMachine/pkg/Cell"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Writer stores per-turn statistics of the simulation
+type Writer interface {
+	Write(turn uint64, stats Cell.FieldStats) error
+	Close() error
+}
+
+// NewWriter creates a file writer of the format. Empty format is detected by the file extension.
+func NewWriter(path string, format string) (Writer, error) {
+	if format == "" {
+		format = FormatCSV
+		ext := filepath.Ext(path)
+		if ext == ".json" || ext == ".jsonl" {
+			format = FormatJSON
+		}
+	}
+	if format != FormatCSV && format != FormatJSON {
+		return nil, errors.New("unknown metrics format " + format)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	buffer := bufio.NewWriter(file)
+	if format == FormatJSON {
+		return &jsonWriter{file: file, buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+	}
+	return &csvWriter{file: file, buffer: buffer, writer: csv.NewWriter(buffer)}, nil
+}
+
+type csvWriter struct {
+	file          *os.File
+	buffer        *bufio.Writer
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func (w *csvWriter) header(stats Cell.FieldStats) []string {
+	header := []string{"turn", "population", "births", "divisions", "moves"}
+	for cause := Cell.DeathCause(0); cause < Cell.DeathCauseCount; cause++ {
+		header = append(header, "deaths_"+cause.String())
+	}
+	header = append(header, "total_food")
+	for _, name := range stats.TraitNames {
+		header = append(header, name+"_mean", name+"_variance", name+"_mutations")
+	}
+	return append(header, "events")
+}
+
+func (w *csvWriter) Write(turn uint64, stats Cell.FieldStats) error {
+	if !w.headerWritten {
+		w.headerWritten = true
+		err := w.writer.Write(w.header(stats))
+		if err != nil {
+			return err
+		}
+	}
+
+	record := []string{
+		strconv.FormatUint(turn, 10),
+		strconv.FormatUint(stats.Population, 10),
+		strconv.FormatUint(stats.Births, 10),
+		strconv.FormatUint(stats.Divisions, 10),
+		strconv.FormatUint(stats.Moves, 10),
+	}
+	for _, deaths := range stats.Deaths {
+		record = append(record, strconv.FormatUint(deaths, 10))
+	}
+	record = append(record, formatFloat(stats.TotalFood))
+	for _, trait := range stats.Traits {
+		record = append(record, formatFloat(trait.Mean), formatFloat(trait.Variance), strconv.FormatUint(trait.Mutations, 10))
+	}
+	events := make([]string, len(stats.Events))
+	for i, event := range stats.Events {
+		events[i] = event.String()
+	}
+	record = append(record, strings.Join(events, "; "))
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	err := w.writer.Error()
+	if err == nil {
+		err = w.buffer.Flush()
+	}
+	closeErr := w.file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// one json object per line
+type jsonWriter struct {
+	file    *os.File
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+type jsonSample struct {
+	Turn       uint64
+	Population uint64
+	Births     uint64
+	Divisions  uint64
+	Moves      uint64
+	Deaths     map[string]uint64
+	TotalFood  float64
+	Traits     map[string]Cell.TraitStats
+	Events     []Cell.Event `json:",omitempty"`
+}
+
+func (w *jsonWriter) Write(turn uint64, stats Cell.FieldStats) error {
+	sample := jsonSample{
+		Turn:       turn,
+		Population: stats.Population,
+		Births:     stats.Births,
+		Divisions:  stats.Divisions,
+		Moves:      stats.Moves,
+		Deaths:     make(map[string]uint64, len(stats.Deaths)),
+		TotalFood:  stats.TotalFood,
+		Traits:     make(map[string]Cell.TraitStats, len(stats.Traits)),
+		Events:     stats.Events,
+	}
+	for cause, deaths := range stats.Deaths {
+		sample.Deaths[Cell.DeathCause(cause).String()] = deaths
+	}
+	for i, trait := range stats.Traits {
+		sample.Traits[stats.TraitNames[i]] = trait
+	}
+	return w.encoder.Encode(sample)
+}
+
+func (w *jsonWriter) Close() error {
+	err := w.buffer.Flush()
+	closeErr := w.file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"bufio"
+	"cellMachine/pkg/Cell"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testStats() []Cell.FieldStats {
+	first := Cell.FieldStats{
+		Population: 10,
+		Births:     3,
+		Divisions:  2,
+		Moves:      1,
+		TotalFood:  12.5,
+		TraitNames: []string{"resistance", "pump"},
+		Traits:     []Cell.TraitStats{{Mean: 2, Variance: 0.25, Mutations: 3}, {Mean: 0.5}},
+		Events:     []Cell.Event{{Kind: Cell.EventFoodDrop, X: 1, Y: 2, R: 3, Volume: 50}},
+	}
+	first.Deaths[Cell.DeathStarvation] = 1
+	second := Cell.FieldStats{
+		Population: 9,
+		TotalFood:  11,
+		TraitNames: first.TraitNames,
+		Traits:     []Cell.TraitStats{{Mean: 2.5, Mutations: 4}, {Mean: 0.5}},
+	}
+	second.Deaths[Cell.DeathRandom] = 1
+	return []Cell.FieldStats{first, second}
+}
+
+func writeStats(t *testing.T, path string, format string) {
+	writer, err := NewWriter(path, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, stats := range testStats() {
+		err = writer.Write(uint64(i+1), stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.csv")
+	writeStats(t, path, "")
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "turn,population,births,divisions,moves," +
+		"deaths_starvation,deaths_antibiotic,deaths_crowding,deaths_wiped,deaths_absorbed,deaths_trade-off,deaths_old-age,deaths_random," +
+		"total_food,resistance_mean,resistance_variance,resistance_mutations,pump_mean,pump_variance,pump_mutations,events\n" +
+		"1,10,3,2,1,1,0,0,0,0,0,0,0,12.5,2,0.25,3,0.5,0,0,food_drop x=1 y=2 r=3 volume=50\n" +
+		"2,9,0,0,0,0,0,0,0,0,0,0,1,11,2.5,0,4,0.5,0,0,\n"
+	if string(bytes) != expected {
+		t.Errorf("got\n%s\nexpected\n%s", bytes, expected)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+	}{
+		{"metrics.jsonl", ""},
+		{"metrics.json", ""},
+		{"metrics.txt", FormatJSON},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.file)
+			writeStats(t, path, test.format)
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			// one object per line
+			samples := make([]jsonSample, 0)
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var sample jsonSample
+				err = json.Unmarshal(scanner.Bytes(), &sample)
+				if err != nil {
+					t.Fatal(err)
+				}
+				samples = append(samples, sample)
+			}
+			if len(samples) != 2 {
+				t.Fatalf("got %d lines, expected 2", len(samples))
+			}
+
+			first := samples[0]
+			if first.Turn != 1 || first.Population != 10 || first.Births != 3 || first.Divisions != 2 || first.Moves != 1 || first.TotalFood != 12.5 {
+				t.Errorf("got counters %+v", first)
+			}
+			if len(first.Deaths) != int(Cell.DeathCauseCount) || first.Deaths["starvation"] != 1 || first.Deaths["random"] != 0 {
+				t.Errorf("got deaths %v", first.Deaths)
+			}
+			traits := map[string]Cell.TraitStats{"resistance": {Mean: 2, Variance: 0.25, Mutations: 3}, "pump": {Mean: 0.5}}
+			if !reflect.DeepEqual(first.Traits, traits) {
+				t.Errorf("got traits %v, expected %v", first.Traits, traits)
+			}
+			if !reflect.DeepEqual(first.Events, testStats()[0].Events) {
+				t.Errorf("got events %v", first.Events)
+			}
+			if samples[1].Turn != 2 || samples[1].Deaths["random"] != 1 || samples[1].Events != nil {
+				t.Errorf("got the second sample %+v", samples[1])
+			}
+		})
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewWriter(filepath.Join(t.TempDir(), "metrics.csv"), "xml")
+	if err == nil {
+		t.Error("unknown format is accepted")
+	}
+}
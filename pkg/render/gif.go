@@ -0,0 +1,161 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"cellMachine/pkg/utils"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+)
+
+const (
+	// delay between frames in 100ths of a second
+	DefaultGIFDelay = 10
+
+	paletteFoodLevels       = 6
+	paletteAntibioticLevels = 8
+	paletteEntityLevels     = 6
+	paletteGridLevels       = 4
+)
+
+// Palette contains colors of cells with different food and antibiotic levels, entity colors
+// and grid lines over them. It is built with the same formulas as the cell and entity colors.
+func Palette() color.Palette {
+	base := blend(color.RGBA{A: 255}, background)
+	palette := make(color.Palette, 0, 256)
+	palette = append(palette, base, color.RGBA{A: 255})
+
+	for food := 0; food < paletteFoodLevels; food++ {
+		for antibiotic := 0; antibiotic < paletteAntibioticLevels; antibiotic++ {
+			cell := utils.Color{
+				A: float64(food) / float64(paletteFoodLevels-1) * 0.6,
+				R: float64(antibiotic) / float64(paletteAntibioticLevels-1),
+				G: 0.3,
+				B: 0.3,
+			}
+			palette = append(palette, blend(base, cell))
+		}
+	}
+	for r := 0; r < paletteEntityLevels; r++ {
+		for g := 0; g < paletteEntityLevels; g++ {
+			for b := 0; b < paletteEntityLevels-1; b++ {
+				entity := utils.Color{
+					A: 0.8,
+					R: float64(r) / float64(paletteEntityLevels-1),
+					G: float64(g) / float64(paletteEntityLevels-1),
+					B: float64(b) / float64(paletteEntityLevels-2),
+				}
+				palette = append(palette, blend(base, entity))
+			}
+		}
+	}
+	// grid lines over cells of different colors
+	for level := 0; level < paletteGridLevels; level++ {
+		value := float64(level) / float64(paletteGridLevels-1)
+		line := blend(color.RGBA{R: uint8(value * 255), G: uint8(value * 77), B: uint8(value * 77), A: 255}, gridColor)
+		palette = append(palette, line, blend(blend(base, utils.Color{A: value * 0.6, G: 0.3, B: 0.3}), gridColor))
+	}
+	return palette
+}
+
+// GIFRecorder writes pictures of the field to an animated GIF as they arrive,
+// so memory does not grow with the length of the recording
+type GIFRecorder struct {
+	path     string
+	cellSize int
+	delay    int
+	palette  color.Palette
+	// nearest palette color lookup is slow, so the results are cached
+	indices map[color.RGBA]uint8
+	file    *os.File
+	buffer  *bufio.Writer
+	frames  int
+}
+
+func NewGIFRecorder(path string, cellSize int, delay int) *GIFRecorder {
+	return &GIFRecorder{
+		path:     path,
+		cellSize: cellSize,
+		delay:    delay,
+		palette:  Palette(),
+		indices:  make(map[color.RGBA]uint8),
+	}
+}
+
+// application extension which makes the animation loop forever
+var gifLoopExtension = []byte{0x21, 0xff, 0x0b, 'N', 'E', 'T', 'S', 'C', 'A', 'P', 'E', '2', '.', '0', 0x03, 0x01, 0x00, 0x00, 0x00}
+
+// gifHeaderSize returns the size of the header, the screen descriptor and the global color table
+func gifHeaderSize(data []byte) int {
+	size := 13
+	if packed := data[10]; packed&0x80 != 0 {
+		size += 3 << ((packed & 0x07) + 1)
+	}
+	return size
+}
+
+func (r *GIFRecorder) Record(turn uint64, composer utils.FieldComposer) error {
+	img := Render(composer, r.cellSize)
+	bounds := img.Bounds()
+	frame := image.NewPaletted(bounds, r.palette)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			index, ok := r.indices[c]
+			if !ok {
+				index = uint8(r.palette.Index(c))
+				r.indices[c] = index
+			}
+			frame.SetColorIndex(x, y, index)
+		}
+	}
+
+	// every frame is encoded as a single frame GIF with the same global palette,
+	// its image block is appended to the file without the header and the trailer
+	var encoded bytes.Buffer
+	err := gif.EncodeAll(&encoded, &gif.GIF{Image: []*image.Paletted{frame}, Delay: []int{r.delay}})
+	if err != nil {
+		return err
+	}
+	data := encoded.Bytes()
+	header := gifHeaderSize(data)
+	if r.file == nil {
+		r.file, err = os.Create(r.path)
+		if err != nil {
+			return err
+		}
+		r.buffer = bufio.NewWriter(r.file)
+		_, _ = r.buffer.Write(data[:header])
+		_, _ = r.buffer.Write(gifLoopExtension)
+	}
+	_, err = r.buffer.Write(data[header : len(data)-1])
+	if err != nil {
+		return err
+	}
+	r.frames++
+	return nil
+}
+
+func (r *GIFRecorder) Frames() int {
+	return r.frames
+}
+
+// Close writes the trailer of the GIF, nothing is written if there are no frames
+func (r *GIFRecorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	// trailer
+	err := r.buffer.WriteByte(0x3b)
+	if err == nil {
+		err = r.buffer.Flush()
+	}
+	closeErr := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
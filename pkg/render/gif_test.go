@@ -0,0 +1,95 @@
+package render
+
+import (
+	"cellMachine/pkg/utils"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// movingComposer makes a field with a blue cell which moves every frame
+func movingComposer(frame int, hex bool) utils.FieldComposer {
+	composer := testComposer(6, 4, hex)
+	composer.Cells[frame%6][0].BackColor = blue
+	return composer
+}
+
+func TestGIFRecorder(t *testing.T) {
+	palette := Palette()
+	tests := []struct {
+		name   string
+		hex    bool
+		frames int
+	}{
+		{"single frame", false, 1},
+		{"animation", false, 5},
+		{"hex animation", true, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.gif")
+			recorder := NewGIFRecorder(path, 4, DefaultGIFDelay)
+			for turn := 0; turn < test.frames; turn++ {
+				err := recorder.Record(uint64(turn), movingComposer(turn, test.hex))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if recorder.Frames() != test.frames {
+				t.Errorf("got %d frames, expected %d", recorder.Frames(), test.frames)
+			}
+			err := recorder.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			animation, err := gif.DecodeAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(animation.Image) != test.frames {
+				t.Fatalf("got %d decoded frames, expected %d", len(animation.Image), test.frames)
+			}
+			// the animation loops forever
+			if animation.LoopCount != 0 {
+				t.Errorf("got loop count %d, expected 0", animation.LoopCount)
+			}
+			for i, frame := range animation.Image {
+				if animation.Delay[i] != DefaultGIFDelay {
+					t.Errorf("frame %d: got delay %d, expected %d", i, animation.Delay[i], DefaultGIFDelay)
+				}
+				// frames are drawn the same way as PNG ones with the nearest colors of the palette
+				expected := Render(movingComposer(i, test.hex), 4)
+				if frame.Bounds() != expected.Bounds() {
+					t.Fatalf("frame %d: got bounds %v, expected %v", i, frame.Bounds(), expected.Bounds())
+				}
+				bounds := expected.Bounds()
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+						if c := frame.At(x, y); c != palette.Convert(expected.RGBAAt(x, y)) {
+							t.Fatalf("frame %d: pixel %d:%d is %v, expected %v", i, x, y, c, expected.RGBAAt(x, y))
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGIFRecorderWithoutFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.gif")
+	err := NewGIFRecorder(path, 4, DefaultGIFDelay).Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file is created without frames")
+	}
+}
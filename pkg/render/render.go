@@ -0,0 +1,138 @@
+package render
+
+import (
+	"cellMachine/pkg/utils"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+const DefaultCellSize = 10
+
+var (
+	background = utils.Color{A: 1.0, R: 1.0, G: 1.0, B: 1.0}
+	// same as the stroke brush of gui
+	gridColor = utils.Color{A: 0.6, R: 0.0, G: 0.0, B: 0.0}
+)
+
+func clamp(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
+
+// blend draws a translucent color over an opaque one
+func blend(dst color.RGBA, src utils.Color) color.RGBA {
+	a := clamp(src.A)
+	mix := func(d uint8, s float64) uint8 {
+		return uint8(float64(d)*(1-a) + clamp(s)*255*a + 0.5)
+	}
+	return color.RGBA{R: mix(dst.R, src.R), G: mix(dst.G, src.G), B: mix(dst.B, src.B), A: 255}
+}
+
+// rowShift is an offset of the row in pixels, odd rows of a hex grid are shifted by a half of a cell
+func rowShift(composer utils.FieldComposer, j, cellSize int) int {
+	if composer.Hex && j&1 == 1 {
+		return cellSize / 2
+	}
+	return 0
+}
+
+// Render draws the field the same way as gui does: cell backgrounds, entity circles and grid lines.
+// Every cell is a square of cellSize pixels, hex cells are drawn as bricks with shifted odd rows.
+func Render(composer utils.FieldComposer, cellSize int) *image.RGBA {
+	width := composer.W * cellSize
+	if composer.Hex {
+		width += cellSize / 2
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, composer.H*cellSize))
+	base := blend(color.RGBA{A: 255}, background)
+	for x := 0; x < width; x++ {
+		for y := 0; y < composer.H*cellSize; y++ {
+			img.SetRGBA(x, y, base)
+		}
+	}
+
+	for i := range composer.Cells {
+		for j := range composer.Cells[i] {
+			cellComposer := &composer.Cells[i][j]
+			cellColor := blend(base, cellComposer.BackColor)
+			entityColor := blend(cellColor, cellComposer.Composer.Color)
+			radius := float64(cellComposer.Composer.Size) * float64(cellSize) * 0.5
+			center := float64(cellSize) * 0.5
+
+			for x := 0; x < cellSize; x++ {
+				for y := 0; y < cellSize; y++ {
+					c := cellColor
+					dx := float64(x) + 0.5 - center
+					dy := float64(y) + 0.5 - center
+					if cellComposer.Composer.Size > 0 && dx*dx+dy*dy <= radius*radius {
+						c = entityColor
+					}
+					img.SetRGBA(i*cellSize+rowShift(composer, j, cellSize)+x, j*cellSize+y, c)
+				}
+			}
+		}
+	}
+
+	// lines
+	bounds := img.Bounds()
+	for i := 1; i < composer.W; i++ {
+		for y := 0; y < bounds.Max.Y; y++ {
+			x := i*cellSize + rowShift(composer, y/cellSize, cellSize)
+			img.SetRGBA(x, y, blend(img.RGBAAt(x, y), gridColor))
+		}
+	}
+	for j := 1; j < composer.H; j++ {
+		for x := 0; x < bounds.Max.X; x++ {
+			img.SetRGBA(x, j*cellSize, blend(img.RGBAAt(x, j*cellSize), gridColor))
+		}
+	}
+	return img
+}
+
+// Recorder receives pictures of the field during the simulation
+type Recorder interface {
+	Record(turn uint64, composer utils.FieldComposer) error
+	Close() error
+}
+
+// FrameWriter writes every received picture to a numbered PNG file
+type FrameWriter struct {
+	dir      string
+	cellSize int
+	frame    int
+}
+
+func NewFrameWriter(dir string, cellSize int) (*FrameWriter, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameWriter{dir: dir, cellSize: cellSize}, nil
+}
+
+func (w *FrameWriter) Record(turn uint64, composer utils.FieldComposer) error {
+	w.frame++
+	file, err := os.Create(filepath.Join(w.dir, fmt.Sprintf("frame_%06d.png", w.frame)))
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, Render(composer, w.cellSize))
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func (w *FrameWriter) Close() error {
+	return nil
+}
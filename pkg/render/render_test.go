@@ -0,0 +1,128 @@
+package render
+
+import (
+	"cellMachine/pkg/utils"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	red   = utils.Color{A: 1, R: 1}
+	green = utils.Color{A: 1, G: 1}
+	blue  = utils.Color{A: 1, B: 1}
+)
+
+// testComposer makes a field with red cells in even rows and green ones in odd rows,
+// the first cell has a blue entity
+func testComposer(w, h int, hex bool) utils.FieldComposer {
+	composer := utils.FieldComposer{W: w, H: h, Hex: hex}
+	composer.Cells = make([][]utils.CellComposer, w)
+	for i := range composer.Cells {
+		composer.Cells[i] = make([]utils.CellComposer, h)
+		for j := range composer.Cells[i] {
+			composer.Cells[i][j].BackColor = red
+			if j%2 == 1 {
+				composer.Cells[i][j].BackColor = green
+			}
+		}
+	}
+	composer.Cells[0][0].Composer = utils.EntityComposer{Color: blue, Size: 0.8}
+	return composer
+}
+
+type pixel struct {
+	x, y  int
+	color color.RGBA
+}
+
+func TestRender(t *testing.T) {
+	opaque := func(c utils.Color) color.RGBA {
+		return blend(color.RGBA{A: 255}, c)
+	}
+	white := opaque(background)
+	tests := []struct {
+		name          string
+		hex           bool
+		width, height int
+		pixels        []pixel
+	}{
+		{
+			"square", false, 30, 20,
+			[]pixel{
+				{0, 0, opaque(red)},
+				// center of the entity circle and the corner of its cell
+				{5, 5, opaque(blue)},
+				{1, 1, opaque(red)},
+				{15, 15, opaque(green)},
+				// grid lines between cells
+				{10, 3, blend(opaque(red), gridColor)},
+				{13, 10, blend(opaque(green), gridColor)},
+			},
+		},
+		{
+			// odd rows are shifted right by a half of a cell
+			"hex", true, 35, 20,
+			[]pixel{
+				{0, 0, opaque(red)},
+				{29, 5, opaque(red)},
+				{32, 5, white},
+				{2, 15, white},
+				{7, 15, opaque(green)},
+				{34, 15, opaque(green)},
+				{15, 13, blend(opaque(green), gridColor)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := Render(testComposer(3, 2, test.hex), 10)
+			if size := img.Bounds().Size(); size != image.Pt(test.width, test.height) {
+				t.Fatalf("got size %v, expected %dx%d", size, test.width, test.height)
+			}
+			for _, p := range test.pixels {
+				if c := img.RGBAAt(p.x, p.y); c != p.color {
+					t.Errorf("pixel %d:%d: got %v, expected %v", p.x, p.y, c, p.color)
+				}
+			}
+		})
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frames")
+	writer, err := NewFrameWriter(dir, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for turn := uint64(1); turn <= 3; turn++ {
+		err = writer.Record(turn*10, testComposer(5, 4, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// frames are numbered in order of recording, not by turns
+	for _, name := range []string{"frame_000001.png", "frame_000002.png", "frame_000003.png"} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("cannot decode %s: %s", name, err.Error())
+		}
+		if size := img.Bounds().Size(); size != image.Pt(20, 16) {
+			t.Errorf("%s: got size %v, expected 20x16", name, size)
+		}
+	}
+}
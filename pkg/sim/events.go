@@ -0,0 +1,87 @@
+package sim
+
+import (
+	"cellMachine/pkg/Cell"
+	"errors"
+)
+
+// actions of scheduled events
+const (
+	ActionDose      = "dose"
+	ActionFeed      = "feed"
+	ActionInoculate = "inoculate"
+	ActionWipe      = "wipe"
+)
+
+var eventActions = []string{ActionDose, ActionFeed, ActionInoculate, ActionWipe}
+
+// event is an intervention which is executed before the turn Turn
+// and then every Every turns until the turn Until if they are specified
+type event struct {
+	Turn  uint64
+	Every uint64
+	Until uint64
+	// dose changes a stressor (antibiotic by default) by Value, negative one lowers it,
+	// feed adds Value of a nutrient (food by default), inoculate puts entities of TypeName,
+	// wipe kills all entities
+	Action    string
+	Substance string
+	TypeName  string
+	Value     float64
+	// whole field if not specified
+	Region *Cell.Region
+}
+
+func (e *event) isScheduled(turn uint64) bool {
+	if turn < e.Turn || (e.Until > 0 && turn > e.Until) {
+		return false
+	}
+	if turn == e.Turn {
+		return true
+	}
+	return e.Every > 0 && (turn-e.Turn)%e.Every == 0
+}
+
+func (e *event) substance(defaultName string) string {
+	if e.Substance == "" {
+		return defaultName
+	}
+	return e.Substance
+}
+
+func (sim *Simulator) executeEvent(e *event) error {
+	switch e.Action {
+	case ActionDose:
+		return sim.field.AddStressor(e.Region, e.substance(Cell.AntibioticName), e.Value)
+	case ActionFeed:
+		return sim.field.AddNutrient(e.Region, e.substance(Cell.FoodName), e.Value)
+	case ActionInoculate:
+		t, ok := sim.config.entityTypes[e.TypeName]
+		if !ok {
+			return errors.New("unknown entity type " + e.TypeName)
+		}
+		return sim.field.Inoculate(e.Region, t)
+	case ActionWipe:
+		killed, err := sim.field.Wipe(e.Region)
+		if err == nil {
+			Log.Printf("%d entities are wiped", killed)
+		}
+		return err
+	}
+	return errors.New("unknown action " + e.Action)
+}
+
+// executeEvents runs all events which are scheduled to the turn
+func (sim *Simulator) executeEvents(turn uint64) {
+	for i := range sim.config.events {
+		e := &sim.config.events[i]
+		if !e.isScheduled(turn) {
+			continue
+		}
+		Log.Printf("Turn %d: executing event %d (%s)", turn, i, e.Action)
+		err := sim.executeEvent(e)
+		if err != nil {
+			Warning.Printf("Event %d failed: %s", i, err.Error())
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sim
+
+import (
+	"cellMachine/pkg/Cell"
+	"testing"
+)
+
+func TestEventSchedule(t *testing.T) {
+	tests := []struct {
+		name  string
+		event event
+		turns []uint64
+	}{
+		{"once", event{Turn: 3}, []uint64{3}},
+		{"repeating", event{Turn: 2, Every: 3}, []uint64{2, 5, 8, 11}},
+		{"repeating until", event{Turn: 2, Every: 3, Until: 8}, []uint64{2, 5, 8}},
+		{"until before the turn", event{Turn: 4, Every: 1, Until: 3}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var turns []uint64
+			for turn := uint64(1); turn <= 12; turn++ {
+				if test.event.isScheduled(turn) {
+					turns = append(turns, turn)
+				}
+			}
+			if len(turns) != len(test.turns) {
+				t.Fatalf("got turns %v, expected %v", turns, test.turns)
+			}
+			for i := range turns {
+				if turns[i] != test.turns[i] {
+					t.Fatalf("got turns %v, expected %v", turns, test.turns)
+				}
+			}
+		})
+	}
+}
+
+const eventFields = `, "Seed": 1,
+	"EntityRects": [{"TypeName": "bug", "X": 0, "Y": 0, "W": 2, "H": 2}],
+	"Events": [
+		{"Turn": 1, "Action": "dose", "Value": 2, "Region": {"X": 5, "Y": 5, "W": 2, "H": 2}},
+		{"Turn": 1, "Every": 2, "Until": 3, "Action": "feed", "Value": 10, "Region": {"X": 8, "Y": 0, "W": 5, "H": 1}},
+		{"Turn": 2, "Action": "inoculate", "TypeName": "bug", "Region": {"X": 5, "Y": 0, "W": 2, "H": 1}},
+		{"Turn": 3, "Action": "wipe", "Region": {"X": 0, "Y": 0, "W": 2, "H": 2}}
+	]`
+
+func TestEventActions(t *testing.T) {
+	sim := newTestSimulator(t, eventFields)
+	cellInfo := func(x, y int) (food, maxFood, antibiotic float64, alive bool) {
+		info, err := sim.field.CellInfo(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.FoodStorage, info.MaxFood, info.BadConditions, info.Entity != nil
+	}
+
+	sim.executeEvents(1)
+	if _, _, antibiotic, _ := cellInfo(5, 5); antibiotic != 3 {
+		t.Errorf("got antibiotic %g in the dosed region, expected 3", antibiotic)
+	}
+	if _, _, antibiotic, _ := cellInfo(4, 4); antibiotic != 1 {
+		t.Errorf("got antibiotic %g outside of the dosed region, expected 1", antibiotic)
+	}
+	// the region is clipped by the field
+	if food, maxFood, _, _ := cellInfo(9, 0); food != 110 || maxFood != 110 {
+		t.Errorf("got food %g of %g in the fed region, expected 110 of 110", food, maxFood)
+	}
+	if food, _, _, _ := cellInfo(7, 0); food != 100 {
+		t.Errorf("got food %g outside of the fed region, expected 100", food)
+	}
+
+	sim.executeEvents(2)
+	if _, _, _, alive := cellInfo(6, 0); !alive {
+		t.Error("no entity in the inoculated region")
+	}
+	if population := sim.field.EntityCount(); population != 6 {
+		t.Errorf("got %d entities after inoculation, expected 6", population)
+	}
+
+	sim.executeEvents(3)
+	if _, _, _, alive := cellInfo(1, 1); alive {
+		t.Error("entity is alive in the wiped region")
+	}
+	if population := sim.field.EntityCount(); population != 2 {
+		t.Errorf("got %d entities after wiping, expected 2", population)
+	}
+	if food, _, _, _ := cellInfo(9, 0); food != 120 {
+		t.Errorf("got food %g after the second feeding, expected 120", food)
+	}
+
+	// changes between turns are counted in stats of the next turn
+	sim.field.Update()
+	stats := sim.field.Stats()
+	if stats.Births != 2 || stats.Deaths[Cell.DeathWiped] != 4 {
+		t.Errorf("got %d births and %d wiped entities, expected 2 and 4", stats.Births, stats.Deaths[Cell.DeathWiped])
+	}
+}
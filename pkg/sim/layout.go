@@ -0,0 +1,130 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// layout describes the initial field by images where every pixel is a cell
+type layout struct {
+	// path to PNG image, absolute or relative to the config file
+	Image string
+	// cell type names by colors in #rrggbb format
+	Cells map[string]string
+	// optional image with entities, it must have the same size as the cell one
+	EntityImage string
+	// entity type names by colors in #rrggbb format
+	Entities map[string]string
+}
+
+// layoutImages are decoded layout images with pixels converted to type names,
+// empty name means the pixel has no type
+type layoutImages struct {
+	w, h     int
+	cells    [][]string
+	entities [][]string
+}
+
+// parseColor parses a color in #rrggbb format
+func parseColor(s string) (uint32, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return 0, fmt.Errorf("color %q is not in #rrggbb format", s)
+	}
+	value, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("color %q is not in #rrggbb format", s)
+	}
+	return uint32(value), nil
+}
+
+func parseColorTable(table map[string]string) (map[uint32]string, error) {
+	colors := make(map[uint32]string, len(table))
+	for _, s := range colorNames(table) {
+		name := table[s]
+		color, err := parseColor(strings.ToLower(s))
+		if err != nil {
+			return nil, err
+		}
+		colors[color] = name
+	}
+	return colors, nil
+}
+
+// readLayoutImage converts every pixel of the image to a type name by the color table,
+// transparent pixels and pixels of unknown colors are left empty
+func readLayoutImage(path string, table map[string]string) ([][]string, image.Rectangle, error) {
+	colors, err := parseColorTable(table)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, image.Rectangle{}, fmt.Errorf("cannot decode %s: %s", path, err.Error())
+	}
+
+	bounds := img.Bounds()
+	names := make([][]string, bounds.Dx())
+	unknown := 0
+	for i := range names {
+		names[i] = make([]string, bounds.Dy())
+		for j := range names[i] {
+			r, g, b, a := img.At(bounds.Min.X+i, bounds.Min.Y+j).RGBA()
+			if a == 0 {
+				continue
+			}
+			color := (r>>8)<<16 | (g>>8)<<8 | b>>8
+			if name, ok := colors[color]; ok {
+				names[i][j] = name
+			} else {
+				unknown++
+			}
+		}
+	}
+	if unknown > 0 {
+		Warning.Printf("%d pixels of %s have colors which are not in the table", unknown, path)
+	}
+	return names, bounds, nil
+}
+
+// relative paths of images are relative to the dir
+func imagePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// load reads layout images, relative paths are relative to the dir
+func (l *layout) load(dir string) (*layoutImages, error) {
+	if l.Image == "" {
+		return nil, errors.New("layout image is not specified")
+	}
+	cells, bounds, err := readLayoutImage(imagePath(dir, l.Image), l.Cells)
+	if err != nil {
+		return nil, err
+	}
+	images := &layoutImages{w: bounds.Dx(), h: bounds.Dy(), cells: cells}
+	if l.EntityImage != "" {
+		entities, entityBounds, err := readLayoutImage(imagePath(dir, l.EntityImage), l.Entities)
+		if err != nil {
+			return nil, err
+		}
+		if entityBounds.Dx() != images.w || entityBounds.Dy() != images.h {
+			return nil, fmt.Errorf("entity image size %dx%d differs from cell image size %dx%d",
+				entityBounds.Dx(), entityBounds.Dy(), images.w, images.h)
+		}
+		images.entities = entities
+	}
+	return images, nil
+}
@@ -0,0 +1,65 @@
+package sim
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeImage(t *testing.T, path string, colors [][]color.RGBA) {
+	img := image.NewRGBA(image.Rect(0, 0, len(colors), len(colors[0])))
+	for i := range colors {
+		for j, c := range colors[i] {
+			img.SetRGBA(i, j, c)
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	err = png.Encode(file, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayoutLoad(t *testing.T) {
+	initLog()
+	dir := t.TempDir()
+	red, green := color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}
+	writeImage(t, filepath.Join(dir, "dish.png"), [][]color.RGBA{{red, green}, {green, {}}, {red, red}})
+	tests := []struct {
+		name      string
+		image     string
+		configDir string
+	}{
+		{"relative", "dish.png", dir},
+		{"absolute", filepath.Join(dir, "dish.png"), t.TempDir()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := layout{Image: test.image, Cells: map[string]string{"#ff0000": "hot"}}
+			images, err := l.load(test.configDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if images.w != 3 || images.h != 2 {
+				t.Fatalf("got size %dx%d, expected 3x2", images.w, images.h)
+			}
+			// green is not in the table and the transparent pixel is empty
+			expected := [][]string{{"hot", ""}, {"", ""}, {"hot", "hot"}}
+			for i := range expected {
+				for j := range expected[i] {
+					if images.cells[i][j] != expected[i][j] {
+						t.Errorf("pixel %d:%d: got %q, expected %q", i, j, images.cells[i][j], expected[i][j])
+					}
+				}
+			}
+		})
+	}
+}
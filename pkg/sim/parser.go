@@ -3,8 +3,10 @@ package sim
 import (
 	"cellMachine/pkg/Cell"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 type cellDrop struct {
@@ -27,6 +29,12 @@ type entityDropRect struct {
 	X, Y, W, H int
 }
 
+type foodDrop struct {
+	Cell.FoodDrop
+	// name of a cell type to stamp instead of adding food
+	CellType string
+}
+
 type parsingStruct struct {
 	CellTypes    []Cell.CellType
 	EntityTypes  []Cell.EntityType
@@ -34,13 +42,39 @@ type parsingStruct struct {
 	Height       int
 	BaseCellType string
 	DropFood     bool
-	CellDrops    []cellDrop
-	EntityDrops  []entityDrop
-	CellRects    []cellDropRect
-	EntityRects  []entityDropRect
+	// detailed setup of random food drops, DropFood is ignored if it is specified
+	FoodDrop *foodDrop
+	Seed     *int64
+	// diffusion coefficients by nutrient or stressor name
+	Diffusion   map[string]float64
+	CellDrops   []cellDrop
+	EntityDrops []entityDrop
+	CellRects   []cellDropRect
+	EntityRects []entityDropRect
+	// procedural patterns of nutrients and stressors applied after cell drops
+	Generators []Cell.Generator
+	// initial field from images, applied before drops
+	Layout *layout
+	// interventions during the simulation
+	Events []event
+	// neighbourhood of cells and behaviour of edges, Moore neighbourhood on a torus by default
+	Topology Cell.Topology
 }
 
-func parseJson(jsonBytes []byte) (*Cell.CellField, error) {
+// simulation setup which is described in config
+type simConfig struct {
+	field       *Cell.CellField
+	cellTypes   map[string]Cell.CellType
+	entityTypes map[string]Cell.EntityType
+	// type names in order of definition
+	cellTypeNames   []string
+	entityTypeNames []string
+	events          []event
+}
+
+// in strict mode any problem found by validation is an error,
+// paths in config are relative to the dir
+func parseJson(jsonBytes []byte, dir string, strict bool) (*simConfig, error) {
 
 	// unmarshalling
 	var unmarshalledObjects parsingStruct
@@ -50,56 +84,117 @@ func parseJson(jsonBytes []byte) (*Cell.CellField, error) {
 		return nil, err
 	}
 
+	var images *layoutImages
+	if unmarshalledObjects.Layout != nil {
+		images, err = unmarshalledObjects.Layout.load(dir)
+		if err != nil {
+			Error.Printf("Cannot load layout: %s", err.Error())
+			return nil, err
+		}
+	}
+
+	problems := validateConfig(&unmarshalledObjects, images)
+	for _, problem := range problems {
+		Warning.Printf("Config problem: %s", problem.Error())
+	}
+	if strict && len(problems) > 0 {
+		return nil, fmt.Errorf("config has %d problems, strict mode does not allow to start", len(problems))
+	}
+	for _, problem := range problems {
+		if problem.Fatal {
+			return nil, fmt.Errorf("cannot create the field: %s", problem.Error())
+		}
+	}
+
 	// definition of cellTypes
-	Cell.MinAntibiotic = 100000
 	cellTypes := make(map[string]Cell.CellType, 0)
+	cellTypeNames := make([]string, 0)
 	for i := range unmarshalledObjects.CellTypes {
 		t := unmarshalledObjects.CellTypes[i]
-		cellTypes[t.Name] = Cell.CellType{Name: t.Name, Antibiotic: t.Antibiotic, FoodStorage: t.FoodStorage}
-		if t.Antibiotic > Cell.MaxAntibiotic {
-			Cell.MaxAntibiotic = t.Antibiotic
-		}
-		if t.Antibiotic < Cell.MinAntibiotic {
-			Cell.MinAntibiotic = t.Antibiotic
-		}
+		cellTypes[t.Name] = t
+		cellTypeNames = append(cellTypeNames, t.Name)
 	}
 
 	// definition of entityTypes
 	entityTypes := make(map[string]Cell.EntityType, 0)
+	entityTypeNames := make([]string, 0)
 	for i := range unmarshalledObjects.EntityTypes {
 		e := unmarshalledObjects.EntityTypes[i]
-		entityTypes[e.Name] = Cell.EntityType{
-			Name:            e.Name,
-			ConsumptionBase: e.ConsumptionBase,
-			Resistance:      e.Resistance,
-			GrownRateBase:   e.GrownRateBase,
-			MutationChance:  e.MutationChance,
-		}
+		entityTypeNames = append(entityTypeNames, e.Name)
+		entityTypes[e.Name] = e
 	}
 
 	// definition a base type for whole field
-	baseType := Cell.BaseCellType()
-	if t, ok := cellTypes[unmarshalledObjects.BaseCellType]; ok {
-		baseType = t
-	} else {
-		Warning.Printf("Base type %s not found", unmarshalledObjects.BaseCellType)
-	}
+	baseType := cellTypes[unmarshalledObjects.BaseCellType]
 
 	var dropFood bool = unmarshalledObjects.DropFood
 
+	// size of the field is defined by layout images
+	if images != nil {
+		unmarshalledObjects.Width = images.w
+		unmarshalledObjects.Height = images.h
+	}
+
+	// all nutrients and stressors which are mentioned in config
+	resources := Cell.NewResources(append([]Cell.CellType{baseType}, unmarshalledObjects.CellTypes...), unmarshalledObjects.EntityTypes)
+	Log.Printf("Nutrients: %v, stressors: %v", resources.Nutrients, resources.Stressors)
+
 	// field creation
 	var field *Cell.CellField
-	field = Cell.NewFieldWithBaseCell(unmarshalledObjects.Width, unmarshalledObjects.Height, baseType)
-	field.DropFood(dropFood)
+	field = Cell.NewFieldWithResources(unmarshalledObjects.Width, unmarshalledObjects.Height, baseType, resources)
+	err = field.SetTopology(unmarshalledObjects.Topology)
+	if err != nil {
+		Warning.Println(err.Error())
+	}
+	// random seed is generated by the field if it is not specified
+	if unmarshalledObjects.Seed != nil {
+		field.SetSeed(*unmarshalledObjects.Seed)
+	}
+	if d := unmarshalledObjects.FoodDrop; d != nil {
+		drop := d.FoodDrop
+		if t, ok := cellTypes[d.CellType]; ok {
+			drop.Stamp = &t
+		} else if d.CellType != "" {
+			Warning.Printf("Type %s not found", d.CellType)
+		}
+		err = field.SetFoodDrop(&drop)
+		if err != nil {
+			Warning.Println(err.Error())
+		}
+	} else {
+		field.DropFood(dropFood)
+	}
+	err = field.SetDiffusion(unmarshalledObjects.Diffusion)
+	if err != nil {
+		Warning.Println(err.Error())
+	}
+
+	// layout
+	if images != nil {
+		Log.Printf("Applying layout %s", unmarshalledObjects.Layout.Image)
+		for i := 0; i < images.w; i++ {
+			for j := 0; j < images.h; j++ {
+				if t, ok := cellTypes[images.cells[i][j]]; ok {
+					_ = field.DropCellRect(i, j, 1, 1, t)
+				}
+				if images.entities == nil {
+					continue
+				}
+				if e, ok := entityTypes[images.entities[i][j]]; ok {
+					_ = field.DropEntityRect(i, j, 1, 1, e)
+				}
+			}
+		}
+	}
 
 	// cell drops
 	for i := range unmarshalledObjects.CellDrops {
 		d := unmarshalledObjects.CellDrops[i]
-		Log.Printf("Dropping cell of type %s in point %d : %d with radius %r", d.TypeName, d.X, d.Y, d.R)
+		Log.Printf("Dropping cell of type %s in point %d : %d with radius %d", d.TypeName, d.X, d.Y, d.R)
 		if t, ok := cellTypes[d.TypeName]; ok {
 			err := field.DropCell(d.X, d.Y, d.R, t)
 			if err != nil {
-				Warning.Printf(err.Error())
+				Warning.Println(err.Error())
 			}
 		} else {
 			Warning.Printf("Type %s not found", d.TypeName)
@@ -109,11 +204,11 @@ func parseJson(jsonBytes []byte) (*Cell.CellField, error) {
 	// entity drops
 	for i := range unmarshalledObjects.EntityDrops {
 		d := unmarshalledObjects.EntityDrops[i]
-		Log.Printf("Dropping entity of type %s in point %d : %d with radius %r", d.TypeName, d.X, d.Y, d.R)
+		Log.Printf("Dropping entity of type %s in point %d : %d with radius %d", d.TypeName, d.X, d.Y, d.R)
 		if e, ok := entityTypes[d.TypeName]; ok {
 			err := field.DropEntity(d.X, d.Y, d.R, e)
 			if err != nil {
-				Warning.Printf(err.Error())
+				Warning.Println(err.Error())
 			}
 		} else {
 			Warning.Printf("Type %s not found", d.TypeName)
@@ -127,13 +222,23 @@ func parseJson(jsonBytes []byte) (*Cell.CellField, error) {
 		if c, ok := cellTypes[r.TypeName]; ok {
 			err := field.DropCellRect(r.X, r.Y, r.W, r.H, c)
 			if err != nil {
-				Warning.Printf(err.Error())
+				Warning.Println(err.Error())
 			}
 		} else {
 			Warning.Printf("Type %s not found", r.TypeName)
 		}
 	}
 
+	// generators
+	for i := range unmarshalledObjects.Generators {
+		g := unmarshalledObjects.Generators[i]
+		Log.Printf("Generating %s pattern of %s from %g to %g", g.Kind, g.Substance, g.From, g.To)
+		err := field.Generate(g)
+		if err != nil {
+			Warning.Println(err.Error())
+		}
+	}
+
 	// entity rects
 	for i := range unmarshalledObjects.EntityRects {
 		r := unmarshalledObjects.EntityRects[i]
@@ -141,17 +246,25 @@ func parseJson(jsonBytes []byte) (*Cell.CellField, error) {
 		if c, ok := entityTypes[r.TypeName]; ok {
 			err := field.DropEntityRect(r.X, r.Y, r.W, r.H, c)
 			if err != nil {
-				Warning.Printf(err.Error())
+				Warning.Println(err.Error())
 			}
 		} else {
 			Warning.Printf("Type %s not found", r.TypeName)
 		}
 	}
+	field.FinishSeeding()
 
-	return field, nil
+	return &simConfig{
+		field:           field,
+		cellTypes:       cellTypes,
+		entityTypes:     entityTypes,
+		cellTypeNames:   cellTypeNames,
+		entityTypeNames: entityTypeNames,
+		events:          unmarshalledObjects.Events,
+	}, nil
 }
 
-func initFieldByJSON(fileName string) (*Cell.CellField, error) {
+func initByJSON(fileName string, strict bool) (*simConfig, error) {
 	Log.Printf("Opening file %s...", fileName)
 	file, err := os.Open(fileName)
 	if err != nil {
@@ -162,7 +275,7 @@ func initFieldByJSON(fileName string) (*Cell.CellField, error) {
 		Warning.Printf("Closing file %s...", fileName)
 		err := file.Close()
 		if err != nil {
-			Error.Printf(err.Error())
+			Error.Println(err.Error())
 		}
 	}()
 
@@ -173,5 +286,5 @@ func initFieldByJSON(fileName string) (*Cell.CellField, error) {
 	}
 
 	Log.Printf("Success. Parsing json...")
-	return parseJson(jsonBytes)
+	return parseJson(jsonBytes, filepath.Dir(fileName), strict)
 }
@@ -2,10 +2,15 @@ package sim
 
 import (
 	"cellMachine/pkg/Cell"
+	"cellMachine/pkg/metrics"
+	"cellMachine/pkg/render"
 	"cellMachine/pkg/utils"
+	"errors"
+	"fmt"
 	"log"
-	"math/rand"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -47,62 +52,245 @@ func (info *SimulationInfo) Turns() uint64 {
 func (info *SimulationInfo) Mutations() uint64 {
 	return info.mutationCounter
 }
+func (info *SimulationInfo) Entities() uint64 {
+	return info.entityCounter
+}
+
+func (info SimulationInfo) String() string {
+	return fmt.Sprintf("turns: %d, mutations: %d, entities: %d",
+		info.turnCounter, info.mutationCounter, info.entityCounter)
+}
 
 func (info *SimulationInfo) Reset() {
 	info.turnCounter = 0
 	info.mutationCounter = 0
 }
 
+type recorderEntry struct {
+	recorder render.Recorder
+	every    uint64
+}
+
 type Simulator struct {
-	field     *Cell.CellField
-	turnTimer time.Ticker
-	ready     bool
-	info      SimulationInfo
+	// refuse to start if config has any problem
+	Strict bool
+
+	field  *Cell.CellField
+	config *simConfig
+	info   SimulationInfo
+	// guards field and info, so they are changed only between turns
+	mutex sync.Mutex
+
+	// zero delay means unthrottled simulation
+	delay  time.Duration
+	paused bool
+	// turns which are requested by Step and not made yet
+	steps int
+	// wakes the simulation loop up after pausing or changing speed
+	wake chan struct{}
+	quit chan struct{}
+	// closed when the simulation loop exits
+	done chan struct{}
+
+	checkpointPath  string
+	checkpointEvery uint64
+	metrics         metrics.Writer
+	recorders       []recorderEntry
+	gif             *render.GIFRecorder
 
 	composerChan chan<- utils.FieldComposer
 }
 
-func (sim *Simulator) Init(configPath string, composerChan chan utils.FieldComposer) {
+func (sim *Simulator) Init(configPath string, composerChan chan utils.FieldComposer) error {
 	initLog()
 	Log.Println("Simulation init")
 
 	sim.composerChan = composerChan
+	sim.delay = turnDelay
+	sim.wake = make(chan struct{}, 1)
 
 	var err error
-	sim.field, err = initFieldByJSON(configPath)
+	sim.config, err = initByJSON(configPath, sim.Strict)
 	if err != nil {
-		Error.Printf(err.Error())
-		panic(err.Error())
+		Error.Println(err.Error())
+		return err
 	}
+	sim.field = sim.config.field
+	sim.info.Reset()
+	sim.info.entityCounter = sim.field.EntityCount()
+	Log.Printf("Random seed: %d", sim.field.Seed())
 
 	sim.sendAsync()
 
-	sim.ready = true
-
 	Log.Println("Ready.")
+	return nil
 }
 
-func (sim *Simulator) turn() {
-	if sim.ready == false {
-		return
+// SetCheckpoint enables saving of the snapshot to the path every given number of turns
+func (sim *Simulator) SetCheckpoint(path string, every uint64) {
+	sim.checkpointPath = path
+	sim.checkpointEvery = every
+}
+
+// SetMetrics enables writing of statistics after every turn
+func (sim *Simulator) SetMetrics(writer metrics.Writer) {
+	sim.mutex.Lock()
+	sim.metrics = writer
+	sim.mutex.Unlock()
+}
+
+// AddRecorder makes the recorder receive a picture of the field every given number of turns
+func (sim *Simulator) AddRecorder(recorder render.Recorder, every uint64) {
+	if every == 0 {
+		every = 1
+	}
+	sim.mutex.Lock()
+	sim.recorders = append(sim.recorders, recorderEntry{recorder: recorder, every: every})
+	sim.mutex.Unlock()
+}
+
+// detachRecorder removes the recorder from the list, the mutex should be locked
+func (sim *Simulator) detachRecorder(recorder render.Recorder) bool {
+	for i := range sim.recorders {
+		if sim.recorders[i].recorder == recorder {
+			sim.recorders = append(sim.recorders[:i], sim.recorders[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveRecorder stops recording and closes the recorder. The recorder is closed
+// without the lock, so the simulation goes on meanwhile.
+func (sim *Simulator) RemoveRecorder(recorder render.Recorder) error {
+	sim.mutex.Lock()
+	found := sim.detachRecorder(recorder)
+	sim.mutex.Unlock()
+	if !found {
+		return errors.New("recorder not found")
+	}
+	return recorder.Close()
+}
+
+// StartRecording starts capturing the field to an animated GIF every given number of turns
+func (sim *Simulator) StartRecording(path string, every uint64) error {
+	if sim.IsRecording() {
+		return errors.New("recording is already started")
+	}
+	recorder := render.NewGIFRecorder(path, render.DefaultCellSize, render.DefaultGIFDelay)
+	sim.mutex.Lock()
+	sim.gif = recorder
+	sim.mutex.Unlock()
+	sim.AddRecorder(recorder, every)
+	Log.Printf("Recording to %s is started", path)
+	return nil
+}
+
+// StopRecording finishes the GIF file
+func (sim *Simulator) StopRecording() error {
+	sim.mutex.Lock()
+	recorder := sim.gif
+	sim.gif = nil
+	frames := 0
+	if recorder != nil {
+		sim.detachRecorder(recorder)
+		frames = recorder.Frames()
+	}
+	sim.mutex.Unlock()
+	if recorder == nil {
+		return errors.New("recording is not started")
+	}
+	Log.Printf("Recording is stopped, %d frames are recorded", frames)
+	return recorder.Close()
+}
+
+func (sim *Simulator) IsRecording() bool {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	return sim.gif != nil
+}
+
+// Close flushes and closes all outputs of the simulation
+func (sim *Simulator) Close() {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	for _, entry := range sim.recorders {
+		err := entry.recorder.Close()
+		if err != nil {
+			Error.Printf("Cannot close recorder: %s", err.Error())
+		}
 	}
-	sim.ready = false
+	sim.recorders = nil
+	sim.gif = nil
+	if sim.metrics != nil {
+		err := sim.metrics.Close()
+		if err != nil {
+			Error.Printf("Cannot close metrics: %s", err.Error())
+		}
+		sim.metrics = nil
+	}
+}
 
+func (sim *Simulator) turn() {
+	sim.mutex.Lock()
 	sim.info.turnCounter++
 
+	sim.executeEvents(sim.info.turnCounter)
 	sim.field.Update()
-	sim.info.mutationCounter = Cell.MutationCounter
+	sim.info.mutationCounter = sim.field.Mutations()
 	sim.info.entityCounter = sim.field.EntityCount()
+	if sim.metrics != nil {
+		err := sim.metrics.Write(sim.info.turnCounter, sim.field.Stats())
+		if err != nil {
+			Error.Printf("Cannot write metrics: %s", err.Error())
+		}
+	}
+	sim.record()
 
 	sim.sendAsync()
-	sim.ready = true
+	turns := sim.info.turnCounter
+	sim.mutex.Unlock()
+
+	if sim.checkpointEvery > 0 && turns%sim.checkpointEvery == 0 {
+		err := sim.SaveSnapshot(sim.checkpointPath)
+		if err != nil {
+			Error.Printf("Cannot save checkpoint: %s", err.Error())
+		}
+	}
 }
 
-func (sim *Simulator) sendAsync() {
+func (sim *Simulator) makeComposer() utils.FieldComposer {
 	composer := sim.field.MakeComposer()
 	composer.Turns = sim.info.turnCounter
 	composer.Mutations = sim.info.mutationCounter
 	composer.Entities = sim.info.entityCounter
+	return composer
+}
+
+func (sim *Simulator) record() {
+	var composer *utils.FieldComposer
+	for _, entry := range sim.recorders {
+		if sim.info.turnCounter%entry.every != 0 {
+			continue
+		}
+		// the same picture is shared between recorders
+		if composer == nil {
+			c := sim.makeComposer()
+			composer = &c
+		}
+		err := entry.recorder.Record(sim.info.turnCounter, *composer)
+		if err != nil {
+			Error.Printf("Cannot record turn %d: %s", sim.info.turnCounter, err.Error())
+		}
+	}
+}
+
+func (sim *Simulator) sendAsync() {
+	// nobody is drawing in headless mode
+	if sim.composerChan == nil {
+		return
+	}
+	composer := sim.makeComposer()
 	select {
 	case sim.composerChan <- composer:
 	default:
@@ -111,22 +299,233 @@ func (sim *Simulator) sendAsync() {
 
 func (sim *Simulator) Start() {
 	Log.Println("Starting simulation...")
-	rand.Seed(time.Now().UnixNano())
-	sim.info.Reset()
-	sim.turnTimer = *time.NewTicker(turnDelay)
-	go func() {
-		for range sim.turnTimer.C {
-			sim.turn()
-			if sim.field.EntityCount() == 0 {
-				Warning.Printf("All cells are dead.")
-				sim.Stop()
-				break
-			}
-		}
-	}()
+	sim.quit = make(chan struct{})
+	sim.done = make(chan struct{})
+	go sim.loop(sim.quit, sim.done)
 }
 
+// Stop waits for the current turn, so the simulation state does not change after it returns
 func (sim *Simulator) Stop() {
 	Log.Println("Stopping simulation...")
-	sim.turnTimer.Stop()
+	if sim.quit != nil {
+		close(sim.quit)
+		<-sim.done
+		sim.quit = nil
+		sim.done = nil
+	}
+}
+
+func (sim *Simulator) loop(quit <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		sim.mutex.Lock()
+		paused, delay := sim.paused, sim.delay
+		step := paused && sim.steps > 0
+		if step {
+			sim.steps--
+		}
+		sim.mutex.Unlock()
+
+		if paused && !step {
+			select {
+			case <-sim.wake:
+				continue
+			case <-quit:
+				return
+			}
+		}
+
+		sim.turn()
+		if sim.Entities() == 0 {
+			Warning.Printf("All cells are dead.")
+			sim.Pause()
+		}
+
+		if step || delay == 0 {
+			select {
+			case <-quit:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-time.After(delay):
+		case <-sim.wake:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (sim *Simulator) notify() {
+	select {
+	case sim.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (sim *Simulator) Pause() {
+	sim.mutex.Lock()
+	sim.paused = true
+	sim.mutex.Unlock()
+	sim.notify()
+}
+
+func (sim *Simulator) Resume() {
+	sim.mutex.Lock()
+	sim.paused = false
+	sim.steps = 0
+	sim.mutex.Unlock()
+	sim.notify()
+}
+
+func (sim *Simulator) IsPaused() bool {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	return sim.paused
+}
+
+// Step pauses the simulation and asks the simulation loop to make given number of turns,
+// so it does not block the caller
+func (sim *Simulator) Step(turns int) {
+	sim.mutex.Lock()
+	sim.paused = true
+	sim.steps += turns
+	sim.mutex.Unlock()
+	sim.notify()
+}
+
+// SetSpeed changes the turn rate. Zero or negative rate means unthrottled simulation.
+func (sim *Simulator) SetSpeed(turnsPerSecond float64) {
+	sim.mutex.Lock()
+	if turnsPerSecond > 0 {
+		sim.delay = time.Duration(float64(time.Second) / turnsPerSecond)
+	} else {
+		sim.delay = 0
+	}
+	sim.mutex.Unlock()
+	sim.notify()
+}
+
+// Inspect returns the current state of the cell
+func (sim *Simulator) Inspect(x, y int) (utils.CellInfo, bool) {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	info, err := sim.field.CellInfo(x, y)
+	return info, err == nil
+}
+
+// EnableLineage starts tracking of every birth and death for the family tree export
+func (sim *Simulator) EnableLineage() {
+	sim.mutex.Lock()
+	sim.field.EnableLineage()
+	sim.mutex.Unlock()
+}
+
+// SaveLineage writes the family tree in Newick format if the file has .nwk or .newick extension
+// and in JSON format otherwise
+func (sim *Simulator) SaveLineage(path string) error {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	lineage := sim.field.Lineage()
+	if lineage == nil {
+		return errors.New("lineage tracking is disabled")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(path)
+	if ext == ".nwk" || ext == ".newick" {
+		err = lineage.WriteNewick(file)
+	} else {
+		err = lineage.WriteJSON(file)
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	Log.Printf("Lineage of %d entities is saved to %s", len(lineage.Records()), path)
+	return nil
+}
+
+func (sim *Simulator) CellTypeNames() []string {
+	return sim.config.cellTypeNames
+}
+
+func (sim *Simulator) EntityTypeNames() []string {
+	return sim.config.entityTypeNames
+}
+
+// Layers returns names of field coloring modes: default one and one per nutrient or stressor
+func (sim *Simulator) Layers() []string {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	return sim.field.Layers()
+}
+
+// SetLayer changes the field coloring, cells are redrawn immediately
+func (sim *Simulator) SetLayer(layer int) {
+	sim.mutex.Lock()
+	sim.field.SetLayer(layer)
+	sim.sendAsync()
+	sim.mutex.Unlock()
+}
+
+// Paint drops a cell or entity type on the field between turns
+func (sim *Simulator) Paint(brush utils.Brush) error {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+
+	var err error
+	if brush.Entity {
+		t, ok := sim.config.entityTypes[brush.TypeName]
+		if !ok {
+			return errors.New("unknown entity type " + brush.TypeName)
+		}
+		if brush.Shape == utils.BrushRect {
+			err = sim.field.DropEntityRect(brush.X, brush.Y, brush.W, brush.H, t)
+		} else {
+			err = sim.field.DropEntity(brush.X, brush.Y, brush.R, t)
+		}
+	} else {
+		t, ok := sim.config.cellTypes[brush.TypeName]
+		if !ok {
+			return errors.New("unknown cell type " + brush.TypeName)
+		}
+		if brush.Shape == utils.BrushRect {
+			err = sim.field.DropCellRect(brush.X, brush.Y, brush.W, brush.H, t)
+		} else {
+			err = sim.field.DropCell(brush.X, brush.Y, brush.R, t)
+		}
+	}
+	sim.info.entityCounter = sim.field.EntityCount()
+	sim.sendAsync()
+	return err
+}
+
+func (sim *Simulator) Entities() uint64 {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	return sim.info.entityCounter
+}
+
+// Run simulates given number of turns one by one without any delay or until
+// all entities are dead. Zero turns means no limit.
+func (sim *Simulator) Run(turns uint64) SimulationInfo {
+	Log.Println("Running simulation...")
+	lastTurn := sim.info.turnCounter + turns
+	for turns == 0 || sim.info.turnCounter < lastTurn {
+		sim.turn()
+		if sim.field.EntityCount() == 0 {
+			Warning.Printf("All cells are dead.")
+			break
+		}
+	}
+	return sim.info
 }
@@ -0,0 +1,87 @@
+package sim
+
+import (
+	"cellMachine/pkg/Cell"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConfig writes the valid config with the fields of the test to a temporary file
+func writeConfig(t *testing.T, fields string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := ioutil.WriteFile(path, []byte(fmt.Sprintf(validConfig, fields)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestSimulator(t *testing.T, fields string) *Simulator {
+	sim := new(Simulator)
+	err := sim.Init(writeConfig(t, fields), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sim
+}
+
+func (sim *Simulator) turns() uint64 {
+	sim.mutex.Lock()
+	defer sim.mutex.Unlock()
+	return sim.info.turnCounter
+}
+
+func TestStep(t *testing.T) {
+	sim := newTestSimulator(t, `, "Seed": 1, "EntityDrops": [{"TypeName": "bug", "X": 5, "Y": 5, "R": 2}]`)
+	sim.Start()
+	defer sim.Stop()
+	sim.Pause()
+
+	// the loop could make a turn before the pause
+	before := sim.turns()
+	sim.Step(3)
+	deadline := time.Now().Add(5 * time.Second)
+	for sim.turns() < before+3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if turns := sim.turns(); turns != before+3 {
+		t.Errorf("got %d turns after 3 steps from turn %d", turns, before)
+	}
+	if !sim.IsPaused() {
+		t.Error("simulation is not paused after steps")
+	}
+}
+
+func TestSeedingIsNotBirth(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     string
+		population uint64
+	}{
+		{"drop", `, "EntityDrops": [{"TypeName": "bug", "X": 5, "Y": 5, "R": 1}]`, 5},
+		{"rect", `, "EntityRects": [{"TypeName": "bug", "X": 2, "Y": 2, "W": 3, "H": 4}]`, 12},
+		{
+			"overlapping drops",
+			`, "EntityDrops": [{"TypeName": "bug", "X": 5, "Y": 5, "R": 1}], "EntityRects": [{"TypeName": "bug", "X": 5, "Y": 5, "W": 2, "H": 1}]`,
+			5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sim := newTestSimulator(t, `, "Seed": 1`+test.fields)
+			if population := sim.field.EntityCount(); population != test.population {
+				t.Fatalf("got %d seeded entities, expected %d", population, test.population)
+			}
+			sim.turn()
+			stats := sim.field.Stats()
+			if stats.Births != 0 || stats.Deaths[Cell.DeathWiped] != 0 {
+				t.Errorf("got %d births and %d wiped entities in the first turn, expected none", stats.Births, stats.Deaths[Cell.DeathWiped])
+			}
+		})
+	}
+}
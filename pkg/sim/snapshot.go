@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"cellMachine/pkg/Cell"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// increase on every incompatible change of the snapshot format
+const snapshotVersion = 7
+
+type infoSnapshot struct {
+	Turns uint64
+}
+
+type snapshot struct {
+	Version int
+	Info    infoSnapshot
+	Field   Cell.FieldSnapshot
+}
+
+// SaveSnapshot writes the full simulation state to the file. The file is replaced atomically,
+// so a crash during saving does not corrupt the previous checkpoint.
+func (sim *Simulator) SaveSnapshot(path string) error {
+	sim.mutex.Lock()
+	s := snapshot{
+		Version: snapshotVersion,
+		Info: infoSnapshot{
+			Turns: sim.info.turnCounter,
+		},
+		Field: sim.field.Snapshot(),
+	}
+	// field snapshot shares slices with the field, so it is marshalled before the next turn
+	bytes, err := json.Marshal(s)
+	sim.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, bytes, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return err
+	}
+	Log.Printf("Snapshot is saved to %s on turn %d", path, s.Info.Turns)
+	return nil
+}
+
+// LoadSnapshot replaces the current simulation state by the one stored in the file
+func (sim *Simulator) LoadSnapshot(path string) error {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s snapshot
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		return err
+	}
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d, expected %d", s.Version, snapshotVersion)
+	}
+	field, err := Cell.NewFieldFromSnapshot(s.Field)
+	if err != nil {
+		return err
+	}
+
+	sim.mutex.Lock()
+	sim.field = field
+	sim.info.turnCounter = s.Info.Turns
+	sim.info.mutationCounter = field.Mutations()
+	sim.info.entityCounter = field.EntityCount()
+	sim.sendAsync()
+	sim.mutex.Unlock()
+
+	Log.Printf("Snapshot %s is loaded on turn %d, random seed: %d", path, s.Info.Turns, field.Seed())
+	return nil
+}
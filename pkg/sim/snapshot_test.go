@@ -0,0 +1,74 @@
+package sim
+
+import (
+	"cellMachine/pkg/Cell"
+	"cellMachine/pkg/metrics"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// every part of the state which is saved in snapshots is used by the config
+const snapshotFields = `, "Seed": 5,
+	"CellTypes": [{"Name": "plain", "FoodStorage": 100, "Antibiotic": 1, "Nutrients": {"iron": 20},
+		"Regeneration": [{"Model": "seasonal", "Rate": 2, "Period": 20, "Amplitude": 0.5}]}],
+	"EntityTypes": [{"Name": "bug", "ConsumptionBase": 2, "Resistance": 5, "GrownRateBase": 0.4, "MutationChance": 0.3,
+		"Needs": {"iron": 0.5}, "MaxAge": 40, "DeathProbability": 0.01,
+		"Motility": {"Probability": 0.3, "Rule": "chemotaxis"},
+		"Division": {"Offspring": 3, "Split": [0.3, 0.3, 0.3]},
+		"Mutation": {"Distribution": "gaussian", "Step": 0.5, "Traits": {"mutationChance": {"Step": 0.05, "Max": 1}}},
+		"Genes": [{"Name": "pump", "Alleles": [1, 0.2], "Expression": "dominant", "Effects": {"resistance": 1}}],
+		"TradeOffs": [{"Trait": "pump", "Cost": "death", "Weight": 0.01}]}],
+	"FoodDrop": {"Probability": 0.2, "Volume": 50, "MinR": 1, "MaxR": 2},
+	"Diffusion": {"food": 0.1, "antibiotic": 0.05},
+	"Events": [{"Turn": 10, "Every": 15, "Action": "dose", "Value": 1, "Region": {"X": 0, "Y": 0, "W": 5, "H": 10}}],
+	"EntityRects": [{"TypeName": "bug", "X": 3, "Y": 3, "W": 4, "H": 4}]`
+
+func runWithMetrics(t *testing.T, sim *Simulator, turns int, path string) string {
+	writer, err := metrics.NewWriter(path, metrics.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.SetMetrics(writer)
+	for turn := 0; turn < turns; turn++ {
+		sim.turn()
+	}
+	sim.Close()
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes)
+}
+
+func TestSnapshotResume(t *testing.T) {
+	dir := t.TempDir()
+	original := runWithMetrics(t, newTestSimulator(t, snapshotFields), 60, filepath.Join(dir, "original.csv"))
+
+	first := newTestSimulator(t, snapshotFields)
+	for turn := 0; turn < 30; turn++ {
+		first.turn()
+	}
+	snapshotPath := filepath.Join(dir, "snapshot.json")
+	err := first.SaveSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := newTestSimulator(t, snapshotFields)
+	err = resumed.LoadSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail := runWithMetrics(t, resumed, 30, filepath.Join(dir, "resumed.csv"))
+
+	// the header and the last 30 turns
+	lines := strings.SplitAfter(original, "\n")
+	expected := lines[0] + strings.Join(lines[31:], "")
+	if tail != expected {
+		t.Errorf("resumed metrics differ from the original run:\n%s\nexpected:\n%s", tail, expected)
+	}
+	if !strings.Contains(original, Cell.EventFoodDrop) {
+		t.Error("there are no food drops in the original run")
+	}
+}
@@ -0,0 +1,669 @@
+package sim
+
+import (
+	"cellMachine/pkg/Cell"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigError is a problem in config with a JSON path to the wrong value
+type ConfigError struct {
+	Path    string
+	Message string
+	// the field cannot be created even without strict mode
+	Fatal bool
+}
+
+func (e ConfigError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+type configValidator struct {
+	config *parsingStruct
+	// field size, it is taken from layout images if they are specified
+	width, height int
+	layout        *layoutImages
+	problems      []ConfigError
+}
+
+func (v *configValidator) add(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *configValidator) addFatal(path string, format string, args ...interface{}) {
+	v.add(path, format, args...)
+	v.problems[len(v.problems)-1].Fatal = true
+}
+
+// maps are checked in order of keys, so problems are reported in the same order on every run
+
+func levelNames(levels map[string]float64) []string {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func colorNames(table map[string]string) []string {
+	colors := make([]string, 0, len(table))
+	for color := range table {
+		colors = append(colors, color)
+	}
+	sort.Strings(colors)
+	return colors
+}
+
+func (v *configValidator) checkPoint(path string, x, y int) {
+	if x < 0 || x >= v.width {
+		v.add(path+".X", "%d is out of field width %d", x, v.width)
+	}
+	if y < 0 || y >= v.height {
+		v.add(path+".Y", "%d is out of field height %d", y, v.height)
+	}
+}
+
+func (v *configValidator) checkType(path string, name string, types map[string]bool) {
+	if !types[name] {
+		v.add(path, "unknown type %q", name)
+	}
+}
+
+func (v *configValidator) checkTypeNames(path string, names []string) map[string]bool {
+	types := make(map[string]bool)
+	for i, name := range names {
+		typePath := fmt.Sprintf("%s[%d].Name", path, i)
+		if name == "" {
+			v.add(typePath, "empty name")
+		} else if types[name] {
+			v.add(typePath, "duplicate name %q", name)
+		}
+		types[name] = true
+	}
+	return types
+}
+
+// checkLevels checks named nutrients or stressors, the reserved name is set by its own field
+func (v *configValidator) checkLevels(path string, levels map[string]float64, reserved string) {
+	for _, name := range levelNames(levels) {
+		value := levels[name]
+		levelPath := fmt.Sprintf("%s.%s", path, name)
+		if name == "" {
+			v.add(path, "empty name")
+		} else if name == reserved {
+			v.add(levelPath, "%q is reserved, use its own field", name)
+		}
+		if value < 0 {
+			v.add(levelPath, "must not be negative, got %g", value)
+		}
+	}
+}
+
+func (v *configValidator) checkGenerator(path string, g Cell.Generator, substances map[string]bool) {
+	known := false
+	for _, kind := range Cell.GeneratorKinds {
+		known = known || kind == g.Kind
+	}
+	if !known {
+		v.add(path+".Kind", "unknown kind %q, expected one of %v", g.Kind, Cell.GeneratorKinds)
+	}
+	if !substances[g.Substance] {
+		v.add(path+".Substance", "unknown nutrient or stressor %q", g.Substance)
+	}
+	if g.From < 0 {
+		v.add(path+".From", "must not be negative, got %g", g.From)
+	}
+	if g.To < 0 {
+		v.add(path+".To", "must not be negative, got %g", g.To)
+	}
+	switch g.Kind {
+	case Cell.GeneratorLinear:
+		if g.X == g.X2 && g.Y == g.Y2 {
+			v.add(path, "start and end points must differ")
+		}
+	case Cell.GeneratorRadial:
+		if g.R <= 0 {
+			v.add(path+".R", "must be positive, got %d", g.R)
+		}
+	case Cell.GeneratorNoise, Cell.GeneratorStripes, Cell.GeneratorCheckerboard:
+		if g.Size <= 0 {
+			v.add(path+".Size", "must be positive, got %d", g.Size)
+		}
+	}
+	if g.Octaves < 0 {
+		v.add(path+".Octaves", "must not be negative, got %d", g.Octaves)
+	}
+	if g.Steps < 0 || g.Steps == 1 {
+		v.add(path+".Steps", "must be zero or at least 2, got %d", g.Steps)
+	}
+	v.checkRegion(path+".Region", g.Region)
+}
+
+func (v *configValidator) checkRegion(path string, region *Cell.Region) {
+	if region == nil {
+		return
+	}
+	v.checkPoint(path, region.X, region.Y)
+	if region.W <= 0 {
+		v.add(path+".W", "must be positive, got %d", region.W)
+	}
+	if region.H <= 0 {
+		v.add(path+".H", "must be positive, got %d", region.H)
+	}
+}
+
+func (v *configValidator) checkEvent(path string, e event, nutrients, stressors, entityTypes map[string]bool) {
+	if e.Turn == 0 {
+		v.add(path+".Turn", "must be positive, turns are counted from 1")
+	}
+	if e.Until > 0 && e.Until < e.Turn {
+		v.add(path+".Until", "%d is before the first turn %d", e.Until, e.Turn)
+	}
+	switch e.Action {
+	case ActionDose:
+		if e.Substance != "" && !stressors[e.Substance] {
+			v.add(path+".Substance", "unknown stressor %q", e.Substance)
+		}
+	case ActionFeed:
+		if e.Substance != "" && !nutrients[e.Substance] {
+			v.add(path+".Substance", "unknown nutrient %q", e.Substance)
+		}
+	case ActionInoculate:
+		v.checkType(path+".TypeName", e.TypeName, entityTypes)
+	case ActionWipe:
+	default:
+		v.add(path+".Action", "unknown action %q, expected one of %v", e.Action, eventActions)
+	}
+	v.checkRegion(path+".Region", e.Region)
+}
+
+func (v *configValidator) checkFoodDrop(path string, d *foodDrop, cellTypes map[string]bool) {
+	if v.config.DropFood {
+		v.add("DropFood", "is ignored because %s is specified", path)
+	}
+	if d.Probability < 0 || d.Probability > 1 {
+		v.add(path+".Probability", "must be in [0, 1], got %g", d.Probability)
+	}
+	if d.Interval == 0 && d.Probability == 0 {
+		v.add(path, "either Interval or Probability must be specified")
+	}
+	if d.Volume < 0 {
+		v.add(path+".Volume", "must not be negative, got %g", d.Volume)
+	}
+	if d.MinR < 0 {
+		v.add(path+".MinR", "must not be negative, got %d", d.MinR)
+	}
+	if d.MaxR < d.MinR {
+		v.add(path+".MaxR", "%d is less than MinR %d", d.MaxR, d.MinR)
+	}
+	if d.CellType != "" {
+		v.checkType(path+".CellType", d.CellType, cellTypes)
+	}
+	v.checkRegion(path+".Region", d.Region)
+}
+
+func (v *configValidator) checkRegeneration(path string, r Cell.Regeneration, nutrients map[string]bool) {
+	known := false
+	for _, model := range Cell.RegenerationModels {
+		known = known || model == r.Model
+	}
+	if !known {
+		v.add(path+".Model", "unknown model %q, expected one of %v", r.Model, Cell.RegenerationModels)
+	}
+	if r.Nutrient != "" && !nutrients[r.Nutrient] {
+		v.add(path+".Nutrient", "unknown nutrient %q", r.Nutrient)
+	}
+	if r.Rate < 0 {
+		v.add(path+".Rate", "must not be negative, got %g", r.Rate)
+	}
+	if r.Model == Cell.RegenerationSeasonal && r.Period <= 0 {
+		v.add(path+".Period", "must be positive, got %g", r.Period)
+	}
+	if r.Amplitude < 0 {
+		v.add(path+".Amplitude", "must not be negative, got %g", r.Amplitude)
+	}
+}
+
+func (v *configValidator) checkMotility(path string, m *Cell.Motility, substances map[string]bool) {
+	if m.Probability < 0 || m.Probability > 1 {
+		v.add(path+".Probability", "must be in [0, 1], got %g", m.Probability)
+	}
+	if m.Cost < 0 {
+		v.add(path+".Cost", "must not be negative, got %g", m.Cost)
+	}
+	switch m.Rule {
+	case "", Cell.MoveRandom:
+	case Cell.MoveChemotaxis:
+		if m.Substance != "" && !substances[m.Substance] {
+			v.add(path+".Substance", "unknown nutrient or stressor %q", m.Substance)
+		}
+	default:
+		v.add(path+".Rule", "unknown rule %q, expected one of %v", m.Rule, Cell.MoveRules)
+	}
+}
+
+func (v *configValidator) checkDivision(path string, d *Cell.Division) {
+	if d.Size < 0 || d.Size > 1 {
+		v.add(path+".Size", "must be in [0, 1], got %g", d.Size)
+	}
+	if d.Offspring < 0 {
+		v.add(path+".Offspring", "must not be negative, got %d", d.Offspring)
+	}
+	if d.MinFree < 0 {
+		v.add(path+".MinFree", "must not be negative, got %d", d.MinFree)
+	}
+	if len(d.Split) == 0 {
+		return
+	}
+	offspring := d.Offspring
+	if offspring == 0 {
+		offspring = Cell.DefaultOffspring
+	}
+	if offspring > 0 && len(d.Split) != offspring {
+		v.add(path+".Split", "expected a share for each of %d daughters, got %d", offspring, len(d.Split))
+	}
+	total := 0.0
+	for k, share := range d.Split {
+		if share <= 0 {
+			v.add(fmt.Sprintf("%s.Split[%d]", path, k), "must be positive, got %g", share)
+		}
+		total += share
+	}
+	if total > 1 {
+		v.add(path+".Split", "shares must not exceed 1 in total, got %g", total)
+	}
+}
+
+func (v *configValidator) checkMutationRule(path string, r Cell.MutationRule) {
+	if r.Probability != nil && (*r.Probability < 0 || *r.Probability > 1) {
+		v.add(path+".Probability", "must be in [0, 1], got %g", *r.Probability)
+	}
+	if r.Distribution != "" {
+		known := false
+		for _, d := range Cell.StepDistributions {
+			known = known || d == r.Distribution
+		}
+		if !known {
+			v.add(path+".Distribution", "unknown distribution %q, expected one of %v", r.Distribution, Cell.StepDistributions)
+		}
+	}
+	if r.Step != nil && *r.Step < 0 {
+		v.add(path+".Step", "must not be negative, got %g", *r.Step)
+	}
+	min := 0.0
+	if r.Min != nil {
+		min = *r.Min
+	}
+	if min < 0 {
+		v.add(path+".Min", "must not be negative, got %g", min)
+	}
+	if r.Max != nil && *r.Max < min {
+		v.add(path+".Max", "must not be less than Min %g, got %g", min, *r.Max)
+	}
+}
+
+func (v *configValidator) checkMutation(path string, m *Cell.Mutation, traits map[string]bool) {
+	v.checkMutationRule(path, m.MutationRule)
+	names := make([]string, 0, len(m.Traits))
+	for name := range m.Traits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := m.Traits[name]
+		traitPath := path + ".Traits." + name
+		if !traits[name] {
+			v.add(traitPath, "unknown trait %q", name)
+		}
+		v.checkMutationRule(traitPath, r)
+	}
+}
+
+func (v *configValidator) checkGenes(path string, genes []Cell.Gene, traits map[string]bool) {
+	names := make(map[string]bool)
+	for k, gene := range genes {
+		genePath := fmt.Sprintf("%s[%d]", path, k)
+		switch {
+		case gene.Name == "":
+			v.add(genePath+".Name", "empty name")
+		case names[gene.Name]:
+			v.add(genePath+".Name", "duplicate gene %q", gene.Name)
+		case gene.Name == Cell.MutationChanceTrait || !traits[gene.Name]:
+			v.add(genePath+".Name", "unknown trait %q", gene.Name)
+		}
+		names[gene.Name] = true
+		if len(gene.Alleles) > 2 {
+			v.add(genePath+".Alleles", "expected one or two alleles, got %d", len(gene.Alleles))
+		}
+		for i, allele := range gene.Alleles {
+			if allele < 0 {
+				v.add(fmt.Sprintf("%s.Alleles[%d]", genePath, i), "must not be negative, got %g", allele)
+			}
+		}
+		if gene.Expression != "" {
+			known := false
+			for _, e := range Cell.Expressions {
+				known = known || e == gene.Expression
+			}
+			if !known {
+				v.add(genePath+".Expression", "unknown expression %q, expected one of %v", gene.Expression, Cell.Expressions)
+			}
+		}
+		for _, name := range levelNames(gene.Effects) {
+			if name == Cell.MutationChanceTrait || !traits[name] {
+				v.add(genePath+".Effects."+name, "unknown trait %q", name)
+			}
+		}
+	}
+}
+
+func (v *configValidator) checkTradeOff(path string, t Cell.TradeOff, traits, nutrients map[string]bool) {
+	if !traits[t.Trait] {
+		v.add(path+".Trait", "unknown trait %q", t.Trait)
+	}
+	known := false
+	for _, kind := range Cell.CostKinds {
+		known = known || kind == t.Cost
+	}
+	if !known {
+		v.add(path+".Cost", "unknown cost %q, expected one of %v", t.Cost, Cell.CostKinds)
+	}
+	if t.Function != "" {
+		known = false
+		for _, f := range Cell.CostFunctions {
+			known = known || f == t.Function
+		}
+		if !known {
+			v.add(path+".Function", "unknown function %q, expected one of %v", t.Function, Cell.CostFunctions)
+		}
+	}
+	if t.Weight < 0 {
+		v.add(path+".Weight", "must not be negative, got %g", t.Weight)
+	}
+	if t.Substance != "" {
+		if t.Cost != Cell.CostConsumption {
+			v.add(path+".Substance", "is used only by %s cost", Cell.CostConsumption)
+		} else if !nutrients[t.Substance] {
+			v.add(path+".Substance", "unknown nutrient %q", t.Substance)
+		}
+	}
+}
+
+func (v *configValidator) checkTopology(path string, t Cell.Topology) {
+	if t.Neighbourhood != "" {
+		known := false
+		for _, n := range Cell.Neighbourhoods {
+			known = known || n == t.Neighbourhood
+		}
+		if !known {
+			v.add(path+".Neighbourhood", "unknown neighbourhood %q, expected one of %v", t.Neighbourhood, Cell.Neighbourhoods)
+		}
+	}
+	if t.Edges != "" {
+		known := false
+		for _, e := range Cell.EdgeModes {
+			known = known || e == t.Edges
+		}
+		if !known {
+			v.add(path+".Edges", "unknown edges %q, expected one of %v", t.Edges, Cell.EdgeModes)
+		}
+	}
+	if t.Radius < 0 {
+		v.add(path+".Radius", "must not be negative, got %d", t.Radius)
+	}
+	// rows of a hex grid are shifted, so they could be wrapped only in pairs
+	if t.Neighbourhood == Cell.NeighbourhoodHex && (t.Edges == "" || t.Edges == Cell.EdgesTorus) && v.height%2 != 0 {
+		v.add(path, "hex grid on a torus needs even height, got %d", v.height)
+	}
+}
+
+func (v *configValidator) validate() {
+	c := v.config
+	v.width, v.height = c.Width, c.Height
+	if v.layout != nil {
+		// size could be omitted, but it must not contradict to the layout
+		if c.Width != 0 && c.Width != v.layout.w {
+			v.add("Width", "%d differs from layout image width %d", c.Width, v.layout.w)
+		}
+		if c.Height != 0 && c.Height != v.layout.h {
+			v.add("Height", "%d differs from layout image height %d", c.Height, v.layout.h)
+		}
+		v.width, v.height = v.layout.w, v.layout.h
+	}
+	if v.width <= 0 {
+		v.addFatal("Width", "must be positive, got %d", v.width)
+	}
+	if v.height <= 0 {
+		v.addFatal("Height", "must be positive, got %d", v.height)
+	}
+	v.checkTopology("Topology", c.Topology)
+
+	// nutrients which are available at least in one cell type
+	provided := make(map[string]bool)
+	names := make([]string, len(c.CellTypes))
+	for i, t := range c.CellTypes {
+		names[i] = t.Name
+		path := fmt.Sprintf("CellTypes[%d]", i)
+		if t.FoodStorage <= 0 {
+			v.add(path+".FoodStorage", "must be positive, got %g", t.FoodStorage)
+		}
+		if t.Antibiotic < 0 {
+			v.add(path+".Antibiotic", "must not be negative, got %g", t.Antibiotic)
+		}
+		v.checkLevels(path+".Nutrients", t.Nutrients, Cell.FoodName)
+		v.checkLevels(path+".Stressors", t.Stressors, Cell.AntibioticName)
+		for name, value := range t.Nutrients {
+			if value > 0 {
+				provided[name] = true
+			}
+		}
+	}
+	cellTypes := v.checkTypeNames("CellTypes", names)
+
+	names = make([]string, len(c.EntityTypes))
+	for i, t := range c.EntityTypes {
+		names[i] = t.Name
+		path := fmt.Sprintf("EntityTypes[%d]", i)
+		if t.Resistance <= 0 {
+			v.add(path+".Resistance", "must be positive, got %g", t.Resistance)
+		}
+		if t.ConsumptionBase < 0 {
+			v.add(path+".ConsumptionBase", "must not be negative, got %g", t.ConsumptionBase)
+		}
+		if t.GrownRateBase < 0 {
+			v.add(path+".GrownRateBase", "must not be negative, got %g", t.GrownRateBase)
+		}
+		if t.MutationChance < 0 || t.MutationChance > 1 {
+			v.add(path+".MutationChance", "must be in [0, 1], got %g", t.MutationChance)
+		}
+		if t.DeathProbability < 0 || t.DeathProbability > 1 {
+			v.add(path+".DeathProbability", "must be in [0, 1], got %g", t.DeathProbability)
+		}
+		if t.Division != nil {
+			v.checkDivision(path+".Division", t.Division)
+		}
+		v.checkLevels(path+".Needs", t.Needs, Cell.FoodName)
+		v.checkLevels(path+".Resistances", t.Resistances, Cell.AntibioticName)
+		for _, name := range levelNames(t.Needs) {
+			if value := t.Needs[name]; value > 0 && name != "" && name != Cell.FoodName && !provided[name] {
+				v.add(fmt.Sprintf("%s.Needs.%s", path, name), "nutrient is not provided by any cell type")
+			}
+		}
+	}
+	entityTypes := v.checkTypeNames("EntityTypes", names)
+
+	if !cellTypes[c.BaseCellType] {
+		v.addFatal("BaseCellType", "unknown type %q", c.BaseCellType)
+	}
+
+	if c.Layout != nil {
+		for _, color := range colorNames(c.Layout.Cells) {
+			v.checkType("Layout.Cells."+color, c.Layout.Cells[color], cellTypes)
+		}
+		for _, color := range colorNames(c.Layout.Entities) {
+			v.checkType("Layout.Entities."+color, c.Layout.Entities[color], entityTypes)
+		}
+	}
+
+	nutrients := map[string]bool{Cell.FoodName: true}
+	stressors := map[string]bool{Cell.AntibioticName: true}
+	for _, t := range c.CellTypes {
+		for name := range t.Nutrients {
+			nutrients[name] = true
+		}
+		for name := range t.Stressors {
+			stressors[name] = true
+		}
+	}
+	for _, t := range c.EntityTypes {
+		for name := range t.Needs {
+			nutrients[name] = true
+		}
+		for name := range t.Resistances {
+			stressors[name] = true
+		}
+	}
+	substances := make(map[string]bool)
+	for name := range nutrients {
+		substances[name] = true
+	}
+	for name := range stressors {
+		substances[name] = true
+	}
+	for i, t := range c.CellTypes {
+		for k, r := range t.Regeneration {
+			v.checkRegeneration(fmt.Sprintf("CellTypes[%d].Regeneration[%d]", i, k), r, nutrients)
+		}
+	}
+
+	// trait names as in metrics
+	traits := map[string]bool{"resistance": true, "grownRateBase": true, "consumptionBase": true, Cell.MutationChanceTrait: true}
+	for name := range nutrients {
+		if name != Cell.FoodName {
+			traits["consumption."+name] = true
+		}
+	}
+	for name := range stressors {
+		if name != Cell.AntibioticName {
+			traits["resistance."+name] = true
+		}
+	}
+	// other names of genes add new traits, names with dots are reserved for nutrients and stressors
+	for _, t := range c.EntityTypes {
+		for _, gene := range t.Genes {
+			if gene.Name != "" && !strings.Contains(gene.Name, ".") {
+				traits[gene.Name] = true
+			}
+		}
+	}
+
+	for i, t := range c.EntityTypes {
+		if t.Motility != nil {
+			v.checkMotility(fmt.Sprintf("EntityTypes[%d].Motility", i), t.Motility, substances)
+		}
+		if t.Mutation != nil {
+			v.checkMutation(fmt.Sprintf("EntityTypes[%d].Mutation", i), t.Mutation, traits)
+		}
+		v.checkGenes(fmt.Sprintf("EntityTypes[%d].Genes", i), t.Genes, traits)
+		for k, tradeOff := range t.TradeOffs {
+			v.checkTradeOff(fmt.Sprintf("EntityTypes[%d].TradeOffs[%d]", i, k), tradeOff, traits, nutrients)
+		}
+	}
+
+	for _, name := range levelNames(c.Diffusion) {
+		rate := c.Diffusion[name]
+		path := "Diffusion." + name
+		if !substances[name] {
+			v.add(path, "unknown nutrient or stressor %q", name)
+		}
+		if rate < 0 || rate > Cell.MaxDiffusion {
+			v.add(path, "must be in [0, %g], got %g", Cell.MaxDiffusion, rate)
+		}
+	}
+
+	for i, g := range c.Generators {
+		path := fmt.Sprintf("Generators[%d]", i)
+		v.checkGenerator(path, g, substances)
+	}
+
+	if c.FoodDrop != nil {
+		v.checkFoodDrop("FoodDrop", c.FoodDrop, cellTypes)
+	}
+
+	for i, e := range c.Events {
+		path := fmt.Sprintf("Events[%d]", i)
+		v.checkEvent(path, e, nutrients, stressors, entityTypes)
+	}
+
+	for i, d := range c.CellDrops {
+		path := fmt.Sprintf("CellDrops[%d]", i)
+		v.checkType(path+".TypeName", d.TypeName, cellTypes)
+		v.checkPoint(path, d.X, d.Y)
+		if d.R < 0 {
+			v.add(path+".R", "must not be negative, got %d", d.R)
+		}
+	}
+	for i, d := range c.EntityDrops {
+		path := fmt.Sprintf("EntityDrops[%d]", i)
+		v.checkType(path+".TypeName", d.TypeName, entityTypes)
+		v.checkPoint(path, d.X, d.Y)
+		if d.R < 0 {
+			v.add(path+".R", "must not be negative, got %d", d.R)
+		}
+	}
+	for i, r := range c.CellRects {
+		path := fmt.Sprintf("CellRects[%d]", i)
+		v.checkType(path+".TypeName", r.TypeName, cellTypes)
+		v.checkPoint(path, r.X, r.Y)
+		if r.W <= 0 {
+			v.add(path+".W", "must be positive, got %d", r.W)
+		}
+		if r.H <= 0 {
+			v.add(path+".H", "must be positive, got %d", r.H)
+		}
+	}
+	for i, r := range c.EntityRects {
+		path := fmt.Sprintf("EntityRects[%d]", i)
+		v.checkType(path+".TypeName", r.TypeName, entityTypes)
+		v.checkPoint(path, r.X, r.Y)
+		if r.W <= 0 {
+			v.add(path+".W", "must be positive, got %d", r.W)
+		}
+		if r.H <= 0 {
+			v.add(path+".H", "must be positive, got %d", r.H)
+		}
+	}
+}
+
+func validateConfig(config *parsingStruct, layout *layoutImages) []ConfigError {
+	v := configValidator{config: config, layout: layout}
+	v.validate()
+	return v.problems
+}
+
+// ValidateConfig reads the config file and returns all problems found in it
+func ValidateConfig(fileName string) ([]ConfigError, error) {
+	jsonBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var config parsingStruct
+	err = json.Unmarshal(jsonBytes, &config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Layout == nil {
+		return validateConfig(&config, nil), nil
+	}
+	images, err := config.Layout.load(filepath.Dir(fileName))
+	if err != nil {
+		// other problems are still reported, but the size of the field is unknown
+		return append([]ConfigError{{Path: "Layout", Message: err.Error()}}, validateConfig(&config, nil)...), nil
+	}
+	return validateConfig(&config, images), nil
+}
@@ -0,0 +1,105 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fields of a case replace fields of the valid config, the last duplicate key wins in JSON
+const validConfig = `{
+	"Width": 10, "Height": 10, "BaseCellType": "plain",
+	"CellTypes": [{"Name": "plain", "FoodStorage": 100, "Antibiotic": 1}],
+	"EntityTypes": [{"Name": "bug", "ConsumptionBase": 1, "Resistance": 5, "GrownRateBase": 0.3, "MutationChance": 0.1}]
+	%s
+}`
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   string
+		problems []ConfigError
+	}{
+		{"valid", ``, nil},
+		{
+			"field size",
+			`, "Width": 0, "Height": -3`,
+			[]ConfigError{
+				{Path: "Width", Message: "must be positive, got 0", Fatal: true},
+				{Path: "Height", Message: "must be positive, got -3", Fatal: true},
+			},
+		},
+		{
+			"base cell type",
+			`, "BaseCellType": "rock"`,
+			[]ConfigError{{Path: "BaseCellType", Message: `unknown type "rock"`, Fatal: true}},
+		},
+		{
+			"entity drop",
+			`, "EntityDrops": [{"TypeName": "ghost", "X": 12, "Y": -1, "R": -2}]`,
+			[]ConfigError{
+				{Path: "EntityDrops[0].TypeName", Message: `unknown type "ghost"`},
+				{Path: "EntityDrops[0].X", Message: "12 is out of field width 10"},
+				{Path: "EntityDrops[0].Y", Message: "-1 is out of field height 10"},
+				{Path: "EntityDrops[0].R", Message: "must not be negative, got -2"},
+			},
+		},
+		{
+			"nutrients in order of names",
+			`, "CellTypes": [{"Name": "plain", "FoodStorage": 100, "Nutrients": {"zinc": -1, "food": 5, "iron": -2}}]`,
+			[]ConfigError{
+				{Path: "CellTypes[0].Nutrients.food", Message: `"food" is reserved, use its own field`},
+				{Path: "CellTypes[0].Nutrients.iron", Message: "must not be negative, got -2"},
+				{Path: "CellTypes[0].Nutrients.zinc", Message: "must not be negative, got -1"},
+			},
+		},
+		{
+			"duplicate type",
+			`, "CellTypes": [{"Name": "plain", "FoodStorage": 100}, {"Name": "plain", "FoodStorage": 0}]`,
+			[]ConfigError{
+				{Path: "CellTypes[1].FoodStorage", Message: "must be positive, got 0"},
+				{Path: "CellTypes[1].Name", Message: `duplicate name "plain"`},
+			},
+		},
+		{
+			"division",
+			`, "EntityTypes": [{"Name": "bug", "Resistance": 5, "Division": {"Size": 2, "Offspring": 3, "Split": [0.75, 0.5]}}]`,
+			[]ConfigError{
+				{Path: "EntityTypes[0].Division.Size", Message: "must be in [0, 1], got 2"},
+				{Path: "EntityTypes[0].Division.Split", Message: "expected a share for each of 3 daughters, got 2"},
+				{Path: "EntityTypes[0].Division.Split", Message: "shares must not exceed 1 in total, got 1.25"},
+			},
+		},
+		{
+			"mutation traits in order of names",
+			`, "EntityTypes": [{"Name": "bug", "Resistance": 5, "Mutation": {"Traits": {"resistance": {"Step": -1}, "foo": {}}}}]`,
+			[]ConfigError{
+				{Path: "EntityTypes[0].Mutation.Traits.foo", Message: `unknown trait "foo"`},
+				{Path: "EntityTypes[0].Mutation.Traits.resistance.Step", Message: "must not be negative, got -1"},
+			},
+		},
+		{
+			"diffusion",
+			`, "Diffusion": {"food": 0.5, "salt": 0.1}`,
+			[]ConfigError{
+				{Path: "Diffusion.food", Message: "must be in [0, 0.25], got 0.5"},
+				{Path: "Diffusion.salt", Message: `unknown nutrient or stressor "salt"`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var config parsingStruct
+			err := json.Unmarshal([]byte(fmt.Sprintf(validConfig, test.fields)), &config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems := validateConfig(&config, nil)
+			if !reflect.DeepEqual(problems, test.problems) {
+				t.Errorf("got problems %v, expected %v", problems, test.problems)
+			}
+		})
+	}
+}
@@ -51,6 +51,8 @@ type FieldComposer struct {
 	Cells                      [][]CellComposer
 	W, H                       int
 	Turns, Mutations, Entities uint64
+	// cells are hexagons, odd rows are shifted right by a half of a cell
+	Hex bool
 }
 
 func MakeFieldComposer(w, h int) FieldComposer {
@@ -73,3 +75,65 @@ func DefaultFieldComposer(w, h int) FieldComposer {
 	}
 	return composer
 }
+
+// info is a detailed description of a cell and its entity for the inspector
+
+// Level is a named value of a nutrient or a stressor
+type Level struct {
+	Name  string
+	Value float64
+	Max   float64
+}
+
+type EntityInfo struct {
+	Resistance      float64
+	GrownRateBase   float64
+	ConsumptionBase float64
+	MutationChance  float64
+	Size            Size
+	// lineage
+	ID         uint64
+	ParentID   uint64
+	TypeName   string
+	Generation uint64
+	BirthTurn  uint64
+	// zero max age means no limit
+	Age, MaxAge uint64
+	// consumption of every nutrient and resistance to every stressor
+	Needs       []Level
+	Resistances []Level
+	// expressed values of additional genes
+	Genes []Level
+	// empty if the entity cannot move
+	Motility string
+}
+
+type CellInfo struct {
+	X, Y          int
+	FoodStorage   float64
+	MaxFood       float64
+	BadConditions float64
+	Nutrients     []Level
+	Stressors     []Level
+	// nil if the cell is empty
+	Entity *EntityInfo
+}
+
+type BrushShape int
+
+const (
+	BrushCircle BrushShape = iota
+	BrushRect
+)
+
+// Brush describes an area of the field painted by user with a cell or entity type
+type Brush struct {
+	Shape BrushShape
+	// paint entities instead of cells
+	Entity   bool
+	TypeName string
+	X, Y     int
+	// radius for circles, width and height for rectangles
+	R    int
+	W, H int
+}
@@ -0,0 +1,31 @@
+//go:build !headless
+
+package main
+
+import (
+	"cellMachine/pkg/gui"
+	"cellMachine/pkg/sim"
+	"cellMachine/pkg/utils"
+	"github.com/andlabs/ui"
+)
+
+func runWindow(opts options) {
+	closeApp := make(chan bool)
+	composerChan := make(chan utils.FieldComposer)
+	readyChan := make(chan utils.Ready)
+
+	var simulator sim.Simulator
+	core := gui.Uicore{CloseApp: closeApp, ComposerChan: composerChan, ReadyChan: readyChan, Controller: &simulator}
+	initSimulator(&simulator, opts, composerChan)
+	go ui.Main(core.Init)
+
+	// waiting for UI initialisation
+	<-readyChan
+	go simulator.Start()
+
+	<-closeApp
+	simulator.Stop()
+	finishSimulator(&simulator, opts)
+	close(closeApp)
+	close(composerChan)
+}
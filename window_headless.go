@@ -0,0 +1,11 @@
+//go:build headless
+
+package main
+
+import "os"
+
+// the application is built without the ui library, so only headless runs are possible
+func runWindow(opts options) {
+	Error.Println("The application is built without GUI support. Use -headless flag.")
+	os.Exit(1)
+}